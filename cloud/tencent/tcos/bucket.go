@@ -207,10 +207,19 @@ func (c *BucketClient) Delete() error {
 	return nil
 }
 
+// Ping tests the bucket is available, for health checks.
+func (c *BucketClient) Ping(ctx context.Context) error {
+	return headBucket(ctx, c.Client)
+}
+
 // HeadBucket tests bucket is available or not
 // Status: 200 - ok, 403 - Forbidden, 404 - Not Found
 func HeadBucket(client *cos.Client) error {
-	resp, err := client.Bucket.Head(context.Background())
+	return headBucket(context.Background(), client)
+}
+
+func headBucket(ctx context.Context, client *cos.Client) error {
+	resp, err := client.Bucket.Head(ctx)
 	if resp != nil {
 		switch resp.StatusCode {
 		case 200:
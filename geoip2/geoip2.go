@@ -10,6 +10,7 @@ package geoip2
 import (
 	"archive/tar"
 	"compress/gzip"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -22,6 +23,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/TechCatsLab/apix/metrics"
 	"github.com/oschwald/maxminddb-golang"
 	"golang.org/x/sync/errgroup"
 )
@@ -95,6 +97,11 @@ type (
 	}
 )
 
+var (
+	lookups        = metrics.NewCounter("geoip2_lookups_total", "Number of geoip2 lookups by result.", "result")
+	lookupDuration = metrics.NewHistogram("geoip2_lookup_duration_seconds", "Latency of geoip2 lookups in seconds.", nil)
+)
+
 // DefaultClient ...
 var DefaultClient = &Client{
 	DBLocationDir: "maxminddb",
@@ -302,8 +309,38 @@ func (c *Client) DBMeta() ([]DBMeta, error) {
 	return []DBMeta{*asnMeta, *cityMeta}, nil
 }
 
+// Ping checks the databases are loaded and open, for health checks.
+func (c *Client) Ping(_ context.Context) error {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	if c.AsnDB == nil || c.CityDB == nil {
+		return errors.New("no database")
+	}
+
+	probe := net.IPv4(1, 1, 1, 1)
+	if _, err := c.AsnDB.LookupOffset(probe); err != nil {
+		return err
+	}
+	_, err := c.CityDB.LookupOffset(probe)
+	return err
+}
+
 // Lookup for ip geo information
 func (c *Client) Lookup(ipStr string) (*Result, error) {
+	start := time.Now()
+	result, err := c.lookup(ipStr)
+	lookupDuration.Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		lookups.Inc("error")
+	} else {
+		lookups.Inc("ok")
+	}
+	return result, err
+}
+
+func (c *Client) lookup(ipStr string) (*Result, error) {
 	c.MaxConnect--
 	c.mux.RLock()
 	defer func() {
@@ -0,0 +1,150 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package server
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+)
+
+var (
+	errNoAdminAddress = errors.New("admin listener requires an address")
+	errNoAdminHandler = errors.New("admin listener requires importing github.com/TechCatsLab/apix/http/server/admin")
+
+	loopbackCIDRs = []string{"127.0.0.0/8", "::1"}
+
+	adminMu      sync.RWMutex
+	adminHandler AdminHandlerFunc
+)
+
+// AdminConfiguration enables a listener serving the debugging endpoints, apart from the
+// public ones. The endpoints are served by the package
+// github.com/TechCatsLab/apix/http/server/admin, which must be imported:
+//
+//	import _ "github.com/TechCatsLab/apix/http/server/admin"
+//
+// Requests must come from AllowedCIDRs and carry the basic auth credentials if both are
+// set. Without either, only loopback clients are allowed.
+type AdminConfiguration struct {
+	// Network is "tcp", "tcp4", "tcp6" or "unix", "tcp" if it is empty.
+	Network string
+	// Address is a host:port pair such as "localhost:6060", or a socket path for unix.
+	Address string
+	// AllowedCIDRs are the networks allowed to connect over TCP, such as "10.0.0.0/8".
+	AllowedCIDRs []string
+	// Username and Password enable basic authentication.
+	Username string
+	Password string
+}
+
+// AdminHandlerFunc returns the handler of the admin listener of ep, router is the Router
+// passed to Start, or nil.
+type AdminHandlerFunc func(ep *Entrypoint, router *Router) http.Handler
+
+// RegisterAdminHandler sets the handler of the admin listeners. It is called by the
+// package github.com/TechCatsLab/apix/http/server/admin, so that the programs which do not
+// import it do not link the debugging endpoints.
+func RegisterAdminHandler(f AdminHandlerFunc) {
+	adminMu.Lock()
+	adminHandler = f
+	adminMu.Unlock()
+}
+
+// newAdminListener creates the admin listener, router is passed to the admin handler.
+// The admin server has no write timeout, for long profiles.
+func (ep *Entrypoint) newAdminListener(conf *AdminConfiguration, router *Router) (*listener, error) {
+	if conf.Address == "" {
+		return nil, errNoAdminAddress
+	}
+
+	lc := ListenerConfiguration{Network: conf.Network, Address: conf.Address}
+	switch lc.Network {
+	case "":
+		lc.Network = "tcp"
+	case "tcp", "tcp4", "tcp6", "unix":
+	default:
+		return nil, errNetwork
+	}
+
+	handler, err := ep.adminAccess(conf, router)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := ep.listen(lc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &listener{
+		conf:     lc,
+		key:      listenerKey(lc.Network, lc.Address),
+		raw:      raw,
+		listener: raw,
+		admin:    true,
+		server: &http.Server{
+			Addr:              lc.Address,
+			Handler:           handler,
+			ReadHeaderTimeout: ep.configuration.ReadHeaderTimeout,
+			IdleTimeout:       ep.configuration.IdleTimeout,
+		},
+	}, nil
+}
+
+// adminAccess returns the registered admin handler behind the access control of conf.
+func (ep *Entrypoint) adminAccess(conf *AdminConfiguration, router *Router) (http.Handler, error) {
+	adminMu.RLock()
+	f := adminHandler
+	adminMu.RUnlock()
+
+	if f == nil {
+		return nil, errNoAdminHandler
+	}
+
+	cidrs := conf.AllowedCIDRs
+	if len(cidrs) == 0 && conf.Username == "" {
+		cidrs = loopbackCIDRs
+	}
+
+	allowed, err := parseCIDRs(cidrs)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := f(ep, router)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(allowed) > 0 {
+			// A unix socket is protected by its file mode, it has no client address.
+			if ip := net.ParseIP(remoteIP(r.RemoteAddr)); ip != nil && !containsIP(allowed, ip) {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+		}
+
+		if conf.Username != "" && !checkBasicAuth(r, conf.Username, conf.Password) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="admin"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	}), nil
+}
+
+func checkBasicAuth(r *http.Request, username, password string) bool {
+	user, pass, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+
+	userOK := subtle.ConstantTimeCompare([]byte(user), []byte(username)) == 1
+	passOK := subtle.ConstantTimeCompare([]byte(pass), []byte(password)) == 1
+	return userOK && passOK
+}
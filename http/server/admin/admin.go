@@ -0,0 +1,138 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+// Package admin serves the debugging endpoints of the admin listener of a
+// server.Entrypoint, enabled by server.Configuration.Admin:
+//
+//	/debug/pprof/    net/http/pprof profiles
+//	/debug/vars      expvar variables
+//	/debug/runtime   memory, goroutine and GC statistics
+//	/debug/routes    routes registered on the Router passed to Start
+//	/debug/loglevel  the level of server.Logf, changed by PUT /debug/loglevel?level=debug
+//
+// The package registers its handler when it is imported:
+//
+//	import _ "github.com/TechCatsLab/apix/http/server/admin"
+//
+// Importing it also registers /debug/pprof/ and /debug/vars on http.DefaultServeMux, as
+// net/http/pprof and expvar do, the programs serving http.DefaultServeMux publicly should
+// not import it.
+package admin
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"github.com/TechCatsLab/apix/http/server"
+)
+
+var processStart = time.Now()
+
+func init() {
+	server.RegisterAdminHandler(Handler)
+}
+
+// Handler returns the debugging endpoints of ep, the routes of router are listed if it
+// is not nil. The access control is applied by the admin listener.
+func Handler(ep *server.Entrypoint, router *server.Router) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("/debug/loglevel", serveLogLevel)
+	mux.HandleFunc("/debug/runtime", func(w http.ResponseWriter, r *http.Request) {
+		serveRuntime(w, ep)
+	})
+	mux.HandleFunc("/debug/routes", func(w http.ResponseWriter, r *http.Request) {
+		var routes []server.Route
+		if router != nil {
+			routes = router.Routes()
+		}
+		writeJSON(w, http.StatusOK, routes)
+	})
+
+	return mux
+}
+
+// RuntimeStats are the statistics served by /debug/runtime.
+type RuntimeStats struct {
+	GoVersion    string        `json:"go_version"`
+	State        string        `json:"state"`
+	Uptime       string        `json:"uptime"`
+	Goroutines   int           `json:"goroutines"`
+	GOMAXPROCS   int           `json:"gomaxprocs"`
+	NumCPU       int           `json:"num_cpu"`
+	HeapAlloc    uint64        `json:"heap_alloc"`
+	HeapSys      uint64        `json:"heap_sys"`
+	HeapObjects  uint64        `json:"heap_objects"`
+	TotalAlloc   uint64        `json:"total_alloc"`
+	Sys          uint64        `json:"sys"`
+	NumGC        uint32        `json:"num_gc"`
+	PauseTotal   time.Duration `json:"pause_total_ns"`
+	LastGC       time.Time     `json:"last_gc"`
+	NextGCTarget uint64        `json:"next_gc"`
+}
+
+func serveRuntime(w http.ResponseWriter, ep *server.Entrypoint) {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	writeJSON(w, http.StatusOK, &RuntimeStats{
+		GoVersion:    runtime.Version(),
+		State:        ep.State().String(),
+		Uptime:       time.Since(processStart).String(),
+		Goroutines:   runtime.NumGoroutine(),
+		GOMAXPROCS:   runtime.GOMAXPROCS(0),
+		NumCPU:       runtime.NumCPU(),
+		HeapAlloc:    m.HeapAlloc,
+		HeapSys:      m.HeapSys,
+		HeapObjects:  m.HeapObjects,
+		TotalAlloc:   m.TotalAlloc,
+		Sys:          m.Sys,
+		NumGC:        m.NumGC,
+		PauseTotal:   time.Duration(m.PauseTotalNs),
+		LastGC:       time.Unix(0, int64(m.LastGC)),
+		NextGCTarget: m.NextGC,
+	})
+}
+
+// serveLogLevel returns the log level, and sets it on PUT or POST with a level parameter.
+func serveLogLevel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case server.GET, server.HEAD:
+	case server.PUT, server.POST:
+		level, err := server.ParseLevel(r.FormValue("level"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		server.SetLogLevel(level)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"level": server.LogLevel().String()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(server.HeaderContentType, server.MIMEApplicationJSONCharsetUTF8)
+	w.WriteHeader(status)
+	w.Write(body)
+}
@@ -0,0 +1,53 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TechCatsLab/apix/http/server"
+)
+
+func TestHandler(t *testing.T) {
+	rt := server.NewRouter()
+	rt.Get("/users/{id}", func(c *server.Context) error { return nil })
+
+	h := Handler(server.NewEntrypoint(&server.Configuration{}, nil), rt)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/debug/routes", nil))
+
+	var routes []server.Route
+	json.NewDecoder(rec.Body).Decode(&routes)
+	if len(routes) != 1 || routes[0].Pattern != "/users/{id}" || routes[0].Methods[0] != server.GET {
+		t.Fatalf("unexpected routes %v", routes)
+	}
+
+	for _, path := range []string{"/debug/pprof/", "/debug/vars", "/debug/runtime"} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: unexpected status %d", path, rec.Code)
+		}
+	}
+
+	var stats RuntimeStats
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/debug/runtime", nil))
+	if err := json.NewDecoder(rec.Body).Decode(&stats); err != nil || stats.State != server.StateIdle.String() || stats.Goroutines == 0 {
+		t.Fatalf("unexpected runtime statistics %+v %v", stats, err)
+	}
+
+	defer server.SetLogLevel(server.LogLevel())
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("PUT", "/debug/loglevel?level=debug", nil))
+	if rec.Code != http.StatusOK || server.LogLevel() != server.LevelDebug {
+		t.Fatalf("log level not changed: %d %s", rec.Code, server.LogLevel())
+	}
+}
@@ -0,0 +1,112 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// withAdminHandler registers f for the duration of a test.
+func withAdminHandler(f AdminHandlerFunc) func() {
+	adminMu.RLock()
+	previous := adminHandler
+	adminMu.RUnlock()
+
+	RegisterAdminHandler(f)
+	return func() {
+		RegisterAdminHandler(previous)
+	}
+}
+
+func TestDefaultServeMuxClean(t *testing.T) {
+	for _, path := range []string{"/debug/pprof/", "/debug/vars"} {
+		if _, pattern := http.DefaultServeMux.Handler(httptest.NewRequest("GET", path, nil)); pattern != "" {
+			t.Fatalf("%s is served by http.DefaultServeMux on %q", path, pattern)
+		}
+	}
+}
+
+func TestAdminListener(t *testing.T) {
+	ep := NewEntrypoint(&Configuration{
+		Address: "127.0.0.1:0",
+		Admin:   &AdminConfiguration{Address: "127.0.0.1:0"},
+	}, nil)
+	if err := ep.Start(http.NotFoundHandler()); err != errNoAdminHandler {
+		ep.Stop()
+		t.Fatalf("an admin listener started without handler: %v", err)
+	}
+
+	var routes *Router
+	defer withAdminHandler(func(_ *Entrypoint, rt *Router) http.Handler {
+		routes = rt
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("admin"))
+		})
+	})()
+
+	rt := NewRouter()
+	if err := ep.Start(rt); err != nil {
+		t.Fatal(err)
+	}
+	defer ep.Stop()
+
+	if routes != rt {
+		t.Fatal("the router was not passed to the admin handler")
+	}
+
+	admin := ep.listeners[len(ep.listeners)-1]
+	resp, err := http.Get("http://" + admin.raw.Addr().String() + "/debug/runtime")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %d", resp.StatusCode)
+	}
+}
+
+func TestAdminAccess(t *testing.T) {
+	defer withAdminHandler(func(_ *Entrypoint, _ *Router) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	})()
+
+	ep := NewEntrypoint(&Configuration{}, nil)
+
+	handler, err := ep.adminAccess(&AdminConfiguration{
+		AllowedCIDRs: []string{"10.0.0.0/8"},
+		Username:     "admin",
+		Password:     "secret",
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		remote string
+		auth   bool
+		status int
+	}{
+		{"192.0.2.1:1234", true, http.StatusForbidden},
+		{"10.0.0.1:1234", false, http.StatusUnauthorized},
+		{"10.0.0.1:1234", true, http.StatusOK},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest("GET", "/debug/loglevel", nil)
+		req.RemoteAddr = c.remote
+		if c.auth {
+			req.SetBasicAuth("admin", "secret")
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != c.status {
+			t.Fatalf("%s auth %v: unexpected status %d", c.remote, c.auth, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,404 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/urfave/negroni"
+)
+
+const (
+	defaultMaxDecompressionRatio = 100
+
+	// ratioThreshold is the decompressed size from which the ratio is enforced, small
+	// bodies of repeated bytes compress far better than the ratio.
+	ratioThreshold = 64 << 10
+)
+
+var (
+	// ErrBodyTooLarge is returned reading a request body over its limit, the Router answers
+	// 413 Request Entity Too Large when a handler returns it.
+	ErrBodyTooLarge = errors.New("request body is too large")
+	// ErrBodyRatio is returned reading a compressed request body which expands over the
+	// decompression ratio, as a decompression bomb does, the Router answers 413.
+	ErrBodyRatio = errors.New("compressed request body expands over the ratio limit")
+
+	errContentEncoding = errors.New("unsupported request Content-Encoding")
+	errBodyConsumed    = errors.New("request body is already being read")
+)
+
+// BodyOptions limits and decodes the request bodies.
+type BodyOptions struct {
+	// MaxBytes is the largest body read by a handler, after decompression. There is no
+	// limit if it is zero.
+	MaxBytes int64
+	// Decompress decodes the bodies with a gzip or deflate Content-Encoding, and answers
+	// 415 Unsupported Media Type to other encodings.
+	Decompress bool
+	// MaxRatio is the largest ratio of a decompressed body to its compressed size, 100 if
+	// it is zero. It is enforced once 64 KB are decompressed.
+	MaxRatio int64
+}
+
+// requestBody reads a request body within its limits, decompressing it lazily so that a
+// route can still change the options before the handler reads it.
+type requestBody struct {
+	mu  sync.Mutex
+	src io.ReadCloser
+	opt BodyOptions
+
+	declared   int64
+	encoding   string
+	reader     io.Reader
+	compressed *countingReader
+	read       int64
+	err        error
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+// LimitBody applies opt to the body of r. The options replace the ones of an entrypoint
+// for a route, so that the limit of a route may be larger or smaller than the global one.
+// It answers 413 or 415 and returns false if the request is rejected, as a filter does.
+func LimitBody(w http.ResponseWriter, r *http.Request, opt BodyOptions) bool {
+	if r.Body == nil || r.Body == http.NoBody {
+		return true
+	}
+
+	body, ok := r.Body.(*requestBody)
+	if !ok {
+		body = &requestBody{src: r.Body, declared: r.ContentLength}
+		r.Body = body
+	}
+
+	if err := body.configure(r, opt); err != nil {
+		status, _ := bodyErrorStatus(err)
+		http.Error(w, err.Error(), status)
+		return false
+	}
+
+	if body.declaredOver(opt) {
+		http.Error(w, ErrBodyTooLarge.Error(), http.StatusRequestEntityTooLarge)
+		return false
+	}
+	return true
+}
+
+// PeekBody returns the first n bytes of the body of r as they were received, before any
+// decompression, and whether the body is longer. The bytes are put back, and the handler
+// still reads the body within the limits of its route.
+func PeekBody(r *http.Request, n int64) ([]byte, bool, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, false, nil
+	}
+
+	src := &r.Body
+	if body, ok := r.Body.(*requestBody); ok {
+		body.mu.Lock()
+		defer body.mu.Unlock()
+
+		if body.reader != nil {
+			return nil, false, errBodyConsumed
+		}
+		src = &body.src
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(*src, n+1))
+	*src = &peekedBody{Reader: io.MultiReader(bytes.NewReader(data), *src), Closer: *src}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if int64(len(data)) > n {
+		return data[:n], true, nil
+	}
+	return data, false, nil
+}
+
+// peekedBody reads the bytes of PeekBody again, then the rest of the body.
+type peekedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// BodyLimit is a filter applying opt to the body of the requests of a route, see LimitBody.
+// The filters run once the middlewares are done, a declared length over the limit of the
+// entrypoint is rejected before, use Route.LimitBody to raise the limit.
+func BodyLimit(opt BodyOptions) FilterFunc {
+	return func(c *Context) bool {
+		return LimitBody(c.Response(), c.Request(), opt)
+	}
+}
+
+// LimitBody applies opt to the request bodies of the route, replacing the options of the
+// entrypoint. The limit of the route is known before the middlewares and the filters run,
+// so that a declared length over it is rejected at once, and a larger limit accepts the
+// bodies declared over the limit of the entrypoint. The options of a versioned route
+// apply to every version of its pattern.
+func (r *Route) LimitBody(opt BodyOptions) *Route {
+	if r.router == nil {
+		return r
+	}
+
+	r.router.mu.Lock()
+	defer r.router.mu.Unlock()
+
+	if r.router.bodyLimits == nil {
+		r.router.bodyLimits = make(map[*mux.Route]BodyOptions)
+	}
+	for _, route := range r.muxRoutes {
+		r.router.bodyLimits[route] = opt
+	}
+	return r
+}
+
+// bodyLimit returns the body options of a route.
+func (rt *Router) bodyLimit(route *mux.Route) (BodyOptions, bool) {
+	rt.mu.RLock()
+	defer rt.mu.RUnlock()
+
+	opt, ok := rt.bodyLimits[route]
+	return opt, ok
+}
+
+// matchBodyLimit returns the body options of the route matching r.
+func (rt *Router) matchBodyLimit(r *http.Request) (BodyOptions, bool) {
+	var match mux.RouteMatch
+	if !rt.router.Match(r, &match) || match.Route == nil {
+		return BodyOptions{}, false
+	}
+	return rt.bodyLimit(match.Route)
+}
+
+// limitBody applies the body options of route before f, and its filters, run.
+func (rt *Router) limitBody(route *mux.Route, f http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if opt, ok := rt.bodyLimit(route); ok && !LimitBody(w, r, opt) {
+			return
+		}
+		f(w, r)
+	}
+}
+
+// bodyHandler applies opt to every request body, and rejects a declared length over the
+// limit before the middlewares run. The limit of the route matching the request, if
+// routes returns it, replaces opt.
+func bodyHandler(opt BodyOptions, routes func(*http.Request) (BodyOptions, bool)) negroni.Handler {
+	return negroni.HandlerFunc(func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		if r.Body != nil && r.Body != http.NoBody {
+			body := &requestBody{src: r.Body, declared: r.ContentLength}
+			r.Body = body
+
+			if err := body.configure(r, opt); err != nil {
+				status, _ := bodyErrorStatus(err)
+				http.Error(w, err.Error(), status)
+				return
+			}
+
+			if body.declaredOver(opt) {
+				// The route may accept a larger body.
+				var (
+					routeOpt BodyOptions
+					ok       bool
+				)
+				if routes != nil {
+					routeOpt, ok = routes(r)
+				}
+
+				if !ok {
+					http.Error(w, ErrBodyTooLarge.Error(), http.StatusRequestEntityTooLarge)
+					return
+				}
+				if !LimitBody(w, r, routeOpt) {
+					return
+				}
+			}
+		}
+
+		next(w, r)
+	})
+}
+
+func (b *requestBody) configure(r *http.Request, opt BodyOptions) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if opt.MaxRatio <= 0 {
+		opt.MaxRatio = defaultMaxDecompressionRatio
+	}
+	b.opt = opt
+
+	if !opt.Decompress || b.reader != nil || b.encoding != "" {
+		return nil
+	}
+
+	encoding := strings.ToLower(strings.TrimSpace(r.Header.Get(HeaderContentEncoding)))
+	switch encoding {
+	case "", "identity":
+		return nil
+	case "gzip", "x-gzip", "deflate":
+	default:
+		return errContentEncoding
+	}
+
+	// The handlers see the decoded body.
+	b.encoding = encoding
+	r.Header.Del(HeaderContentEncoding)
+	r.Header.Del(HeaderContentLength)
+	r.ContentLength = -1
+	return nil
+}
+
+// declaredOver reports whether the declared length of an uncompressed body is over the
+// limit of opt.
+func (b *requestBody) declaredOver(opt BodyOptions) bool {
+	return opt.MaxBytes > 0 && b.encoding == "" && b.declared > opt.MaxBytes
+}
+
+func (b *requestBody) Read(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.err != nil {
+		return 0, b.err
+	}
+
+	if b.reader == nil {
+		if err := b.open(); err != nil {
+			b.err = err
+			return 0, err
+		}
+	}
+
+	limit := b.opt.MaxBytes
+	if limit > 0 {
+		if b.read >= limit {
+			// Tell the limit from a body of exactly limit bytes.
+			var one [1]byte
+			if n, _ := b.reader.Read(one[:]); n == 0 {
+				return 0, io.EOF
+			}
+			b.err = ErrBodyTooLarge
+			return 0, b.err
+		}
+		if int64(len(p)) > limit-b.read {
+			p = p[:limit-b.read]
+		}
+	}
+
+	n, err := b.reader.Read(p)
+	b.read += int64(n)
+
+	if err != nil && err != io.EOF && b.compressed != nil {
+		b.err = err
+	}
+
+	if b.compressed != nil && b.read > ratioThreshold && b.read > b.opt.MaxRatio*b.compressed.n {
+		b.err = ErrBodyRatio
+		return n, b.err
+	}
+	return n, err
+}
+
+// open creates the reader of the body on its first read.
+func (b *requestBody) open() error {
+	if b.encoding == "" {
+		if b.opt.MaxBytes > 0 && b.declared > b.opt.MaxBytes {
+			return ErrBodyTooLarge
+		}
+		b.reader = b.src
+		return nil
+	}
+
+	b.compressed = &countingReader{r: b.src}
+
+	if b.encoding != "deflate" {
+		zr, err := gzip.NewReader(b.compressed)
+		if err != nil {
+			return err
+		}
+		b.reader = zr
+		return nil
+	}
+
+	// deflate is a zlib stream, but some clients send raw deflate data.
+	br := bufio.NewReader(b.compressed)
+	header, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		zr, err := zlib.NewReader(br)
+		if err != nil {
+			return err
+		}
+		b.reader = zr
+		return nil
+	}
+
+	b.reader = flate.NewReader(br)
+	return nil
+}
+
+func (b *requestBody) Close() error {
+	return b.src.Close()
+}
+
+// bodyErrorStatus returns the status answering an error reading a request body.
+func bodyErrorStatus(err error) (int, bool) {
+	switch err {
+	case ErrBodyTooLarge, ErrBodyRatio:
+		return http.StatusRequestEntityTooLarge, true
+	case errContentEncoding:
+		return http.StatusUnsupportedMediaType, true
+	case gzip.ErrHeader, gzip.ErrChecksum, zlib.ErrHeader, zlib.ErrChecksum, zlib.ErrDictionary, io.ErrUnexpectedEOF:
+		return http.StatusBadRequest, true
+	}
+
+	if _, ok := err.(flate.CorruptInputError); ok {
+		return http.StatusBadRequest, true
+	}
+	return 0, false
+}
+
+// bodyError returns the error which stopped reading the body of r, since decoders may
+// replace it with their own.
+func bodyError(r *http.Request) error {
+	body, ok := r.Body.(*requestBody)
+	if !ok {
+		return nil
+	}
+
+	body.mu.Lock()
+	defer body.mu.Unlock()
+
+	if _, ok := bodyErrorStatus(body.err); ok {
+		return body.err
+	}
+	return nil
+}
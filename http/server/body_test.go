@@ -0,0 +1,221 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type item struct {
+	Name string `json:"name"`
+}
+
+func echoItem(c *Context) error {
+	var v item
+	if err := c.JSONBody(&v); err != nil {
+		return err
+	}
+	return c.ServeJSON(v)
+}
+
+// chunked hides the length of the body, as a chunked request does.
+type chunked struct {
+	io.Reader
+}
+
+func bodyRequest(body io.Reader, encoding string) *http.Request {
+	r := httptest.NewRequest(POST, "/items", body)
+	r.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	if encoding != "" {
+		r.Header.Set(HeaderContentEncoding, encoding)
+	}
+	return r
+}
+
+func serveBody(ep *Entrypoint, rt *Router, r *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	ep.Handler(rt).ServeHTTP(rec, r)
+	return rec
+}
+
+func gzipped(s string) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	io.WriteString(zw, s)
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestBodyLimit(t *testing.T) {
+	ep := NewEntrypoint(&Configuration{MaxBodyBytes: 16}, nil)
+
+	var filtered, handled int
+	countFilter := func(c *Context) bool {
+		filtered++
+		return true
+	}
+
+	rt := NewRouter()
+	rt.SetErrorHandler(func(c *Context) {
+		handled++
+	})
+	rt.Post("/items", echoItem, countFilter)
+	rt.Post("/uploads", echoItem).LimitBody(BodyOptions{MaxBytes: 64})
+	rt.Post("/small", echoItem, BodyLimit(BodyOptions{MaxBytes: 8}))
+
+	large := `{"name":"` + strings.Repeat("a", 32) + `"}`
+	larger := `{"name":"` + strings.Repeat("a", 64) + `"}`
+
+	for _, c := range []struct {
+		path   string
+		body   io.Reader
+		status int
+	}{
+		{"/items", strings.NewReader(`{"name":"a"}`), http.StatusOK},
+		{"/items", strings.NewReader(large), http.StatusRequestEntityTooLarge},
+		{"/items", chunked{strings.NewReader(large)}, http.StatusRequestEntityTooLarge},
+		{"/uploads", strings.NewReader(large), http.StatusOK},
+		{"/uploads", chunked{strings.NewReader(larger)}, http.StatusRequestEntityTooLarge},
+		{"/uploads", strings.NewReader(larger), http.StatusRequestEntityTooLarge},
+		{"/small", strings.NewReader(`{"name":"a"}`), http.StatusRequestEntityTooLarge},
+		{"/small", chunked{strings.NewReader(`{"name":"a"}`)}, http.StatusRequestEntityTooLarge},
+	} {
+		r := bodyRequest(c.body, "")
+		r.URL.Path = c.path
+
+		if rec := serveBody(ep, rt, r); rec.Code != c.status {
+			t.Fatalf("%s %T: status %d, expected %d", c.path, c.body, rec.Code, c.status)
+		}
+	}
+
+	// The declared length is rejected before the filters, the body read over the limit
+	// after them, and the answered body errors do not reach the error handler.
+	if filtered != 2 || handled != 0 {
+		t.Fatalf("%d filtered requests, %d errors handled", filtered, handled)
+	}
+}
+
+func TestRouteLimitBody(t *testing.T) {
+	rt := NewRouter()
+	rt.Post("/uploads", echoItem, func(c *Context) bool {
+		t.Fatal("a filter ran for a body over the limit")
+		return false
+	}).LimitBody(BodyOptions{MaxBytes: 8})
+
+	r := bodyRequest(strings.NewReader(`{"name":"a"}`), "")
+	r.URL.Path = "/uploads"
+
+	rec := httptest.NewRecorder()
+	rt.ServeHTTP(rec, r)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("unexpected status %d", rec.Code)
+	}
+}
+
+func TestBodyDecompress(t *testing.T) {
+	ep := NewEntrypoint(&Configuration{MaxBodyBytes: 1 << 20, DecompressBody: true}, nil)
+
+	rt := NewRouter()
+	rt.Post("/items", echoItem)
+
+	var deflated bytes.Buffer
+	zw := zlib.NewWriter(&deflated)
+	io.WriteString(zw, `{"name":"deflate"}`)
+	zw.Close()
+
+	for encoding, body := range map[string][]byte{
+		"gzip":    gzipped(`{"name":"gzip"}`),
+		"deflate": deflated.Bytes(),
+	} {
+		rec := serveBody(ep, rt, bodyRequest(bytes.NewReader(body), encoding))
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), encoding) {
+			t.Fatalf("%s: status %d, body %q", encoding, rec.Code, rec.Body.String())
+		}
+	}
+
+	if rec := serveBody(ep, rt, bodyRequest(strings.NewReader("{}"), "br")); rec.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("unexpected status %d for br", rec.Code)
+	}
+	if rec := serveBody(ep, rt, bodyRequest(strings.NewReader("{}"), "gzip")); rec.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status %d for corrupt gzip", rec.Code)
+	}
+
+	bomb := gzipped(`{"name":"` + strings.Repeat("a", 512<<10) + `"}`)
+	if rec := serveBody(ep, rt, bodyRequest(bytes.NewReader(bomb), "gzip")); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("unexpected status %d for a bomb", rec.Code)
+	}
+}
+
+func TestBodyLimitAfterDecompression(t *testing.T) {
+	ep := NewEntrypoint(&Configuration{MaxBodyBytes: 32, DecompressBody: true, MaxDecompressionRatio: 1 << 20}, nil)
+
+	rt := NewRouter()
+	rt.Post("/items", echoItem)
+
+	body := gzipped(`{"name":"` + strings.Repeat("a", 64) + `"}`)
+	if rec := serveBody(ep, rt, bodyRequest(bytes.NewReader(body), "gzip")); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("unexpected status %d", rec.Code)
+	}
+}
+
+func TestJSONBody(t *testing.T) {
+	decode := func(body io.Reader, opt JSONOptions) error {
+		c := NewContext(httptest.NewRecorder(), bodyRequest(body, ""))
+		var v item
+		return c.JSONBodyWith(&v, opt)
+	}
+
+	if err := decode(chunked{strings.NewReader("")}, JSONOptions{}); err != errNoBody {
+		t.Fatalf("an empty chunked body returned %v", err)
+	}
+	if err := decode(ioutil.NopCloser(strings.NewReader(" ")), JSONOptions{SingleValue: true}); err != errNoBody {
+		t.Fatalf("a blank body returned %v", err)
+	}
+
+	unknown := `{"name":"a","admin":true}`
+	if err := decode(strings.NewReader(unknown), JSONOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := decode(strings.NewReader(unknown), JSONOptions{DisallowUnknownFields: true}); err == nil {
+		t.Fatal("an unknown field was accepted")
+	}
+
+	trailing := `{"name":"a"} {"name":"b"}`
+	if err := decode(strings.NewReader(trailing), JSONOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := decode(strings.NewReader(trailing), JSONOptions{SingleValue: true}); err != errTrailingJSON {
+		t.Fatalf("trailing data returned %v", err)
+	}
+	if err := decode(strings.NewReader(`{"name":"a"}`+"\n"), JSONOptions{SingleValue: true}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRouterJSONOptions(t *testing.T) {
+	rt := NewRouter()
+	rt.SetJSONOptions(JSONOptions{DisallowUnknownFields: true})
+	rt.Post("/items", func(c *Context) error {
+		if err := echoItem(c); err != nil {
+			return c.WriteHeader(http.StatusBadRequest)
+		}
+		return nil
+	})
+
+	rec := httptest.NewRecorder()
+	rt.ServeHTTP(rec, bodyRequest(strings.NewReader(`{"id":1}`), ""))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status %d", rec.Code)
+	}
+}
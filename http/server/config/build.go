@@ -0,0 +1,263 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/TechCatsLab/apix/http/server"
+	"github.com/TechCatsLab/apix/http/server/middleware"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/rs/cors"
+	"github.com/urfave/negroni"
+)
+
+// MiddlewareFactory builds a middleware from the configuration.
+type MiddlewareFactory func(f *File) (negroni.Handler, error)
+
+var (
+	middlewaresMu sync.RWMutex
+	middlewares   = map[string]MiddlewareFactory{
+		"recover": func(_ *File) (negroni.Handler, error) {
+			return middleware.NegroniRecoverHandler(), nil
+		},
+		"logger": func(_ *File) (negroni.Handler, error) {
+			return middleware.NegroniLoggerHandler(), nil
+		},
+		"cors":       buildCORS,
+		"jwt":        buildJWT,
+		"secure-api": secureFactory(middleware.SecureAPI),
+		"secure-web": secureFactory(middleware.SecureWebApp),
+		"secure":     secureFactory(middleware.SecureStrict),
+		"csrf": func(_ *File) (negroni.Handler, error) {
+			return middleware.NegroniCSRFHandler(middleware.DefaultCSRFOptions())
+		},
+		"realip": func(f *File) (negroni.Handler, error) {
+			return middleware.NegroniRealIPHandler(f.TrustedProxies)
+		},
+		"metrics": func(_ *File) (negroni.Handler, error) {
+			return middleware.NegroniMetricsHandler(middleware.DefaultMetricsOptions()), nil
+		},
+		"idempotency": func(_ *File) (negroni.Handler, error) {
+			return middleware.NegroniIdempotencyHandler(middleware.DefaultIdempotencyOptions()), nil
+		},
+		"concurrency": func(_ *File) (negroni.Handler, error) {
+			return middleware.NewConcurrencyLimiter(middleware.DefaultConcurrencyOptions()), nil
+		},
+	}
+)
+
+// RegisterMiddleware makes a middleware available to the middlewares list under name.
+func RegisterMiddleware(name string, factory MiddlewareFactory) {
+	middlewaresMu.Lock()
+	middlewares[name] = factory
+	middlewaresMu.Unlock()
+}
+
+func lookupMiddleware(name string) (MiddlewareFactory, bool) {
+	middlewaresMu.RLock()
+	factory, ok := middlewares[name]
+	middlewaresMu.RUnlock()
+	return factory, ok
+}
+
+// Configuration returns the server.Configuration described by f.
+func (f *File) Configuration() *server.Configuration {
+	return &server.Configuration{
+		Address:               f.Server.Address,
+		ReadTimeout:           time.Duration(f.Server.ReadTimeout),
+		ReadHeaderTimeout:     time.Duration(f.Server.ReadHeaderTimeout),
+		WriteTimeout:          time.Duration(f.Server.WriteTimeout),
+		IdleTimeout:           time.Duration(f.Server.IdleTimeout),
+		MaxHeaderBytes:        f.Server.MaxHeaderBytes,
+		MaxBodyBytes:          f.Server.MaxBodyBytes,
+		DecompressBody:        f.Server.DecompressBody,
+		MaxDecompressionRatio: f.Server.MaxDecompressionRatio,
+		DisableKeepAlives:     f.Server.DisableKeepAlives,
+		MaxConnections:        f.Server.MaxConnections,
+		ShutdownTimeout:       time.Duration(f.Server.ShutdownTimeout),
+		ShutdownDelay:         time.Duration(f.Server.ShutdownDelay),
+		GracefulUpgrade:       f.Server.GracefulUpgrade,
+		UpgradeTimeout:        time.Duration(f.Server.UpgradeTimeout),
+		Listeners:             f.Server.listeners(),
+		HTTP2:                 f.Server.HTTP2.configuration(),
+		ProxyProtocol:         f.Server.ProxyProtocol.configuration(),
+		Admin:                 f.Server.Admin.configuration(),
+	}
+}
+
+func (a *Admin) configuration() *server.AdminConfiguration {
+	if a == nil {
+		return nil
+	}
+
+	return &server.AdminConfiguration{
+		Network:      a.Network,
+		Address:      a.Address,
+		AllowedCIDRs: a.AllowedCIDRs,
+		Username:     a.Username,
+		Password:     a.Password,
+	}
+}
+
+func (p *Proxy) configuration() *server.ProxyProtocolConfiguration {
+	if p == nil {
+		return nil
+	}
+
+	return &server.ProxyProtocolConfiguration{
+		TrustedCIDRs:  p.TrustedCIDRs,
+		HeaderTimeout: time.Duration(p.HeaderTimeout),
+		Required:      p.Required,
+	}
+}
+
+func (h *HTTP2) configuration() *server.HTTP2Configuration {
+	if h == nil {
+		return nil
+	}
+
+	return &server.HTTP2Configuration{
+		H2C:                  h.H2C,
+		MaxConcurrentStreams: h.MaxConcurrentStreams,
+		MaxReadFrameSize:     h.MaxReadFrameSize,
+		IdleTimeout:          time.Duration(h.IdleTimeout),
+	}
+}
+
+func (s *Server) listeners() []server.ListenerConfiguration {
+	var listeners []server.ListenerConfiguration
+
+	for _, l := range s.Listeners {
+		mode, _ := strconv.ParseUint(l.FileMode, 8, 32)
+		listeners = append(listeners, server.ListenerConfiguration{
+			Network:       l.Network,
+			Address:       l.Address,
+			FileMode:      os.FileMode(mode),
+			TLS:           l.TLS.configuration(),
+			RedirectPort:  l.RedirectPort,
+			ProxyProtocol: l.ProxyProtocol.configuration(),
+		})
+	}
+
+	return listeners
+}
+
+// TLSConfiguration returns the server.TLSConfiguration described by f, nil for a http server.
+func (f *File) TLSConfiguration() *server.TLSConfiguration {
+	return f.TLS.configuration()
+}
+
+func (t *TLS) configuration() *server.TLSConfiguration {
+	if t == nil {
+		return nil
+	}
+
+	conf := &server.TLSConfiguration{
+		Cert:           t.Cert,
+		Key:            t.Key,
+		ReloadInterval: time.Duration(t.ReloadInterval),
+		MinVersion:     tlsVersions[t.MinVersion],
+		NextProtos:     t.NextProtos,
+		ClientCAs:      t.ClientCAs,
+		ClientAuth:     clientAuthTypes[t.ClientAuth],
+	}
+
+	for _, cert := range t.Certificates {
+		conf.Certificates = append(conf.Certificates, server.CertificateConfiguration{Cert: cert.Cert, Key: cert.Key})
+	}
+
+	for _, name := range t.CipherSuites {
+		if id, ok := cipherSuite(name); ok {
+			conf.CipherSuites = append(conf.CipherSuites, id)
+		}
+	}
+
+	return conf
+}
+
+// NewEntrypoint creates an Entrypoint from f, with the middlewares attached in the listed order.
+func NewEntrypoint(f *File) (*server.Entrypoint, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+
+	ep := server.NewEntrypoint(f.Configuration(), f.TLSConfiguration())
+
+	for i, name := range f.Middlewares {
+		factory, _ := lookupMiddleware(name)
+
+		handler, err := factory(f)
+		if err != nil {
+			if _, ok := err.(*KeyError); ok {
+				return nil, err
+			}
+			return nil, &KeyError{Key: fmt.Sprintf("middlewares[%d]", i), Err: err}
+		}
+		ep.AttachMiddleware(handler)
+	}
+
+	return ep, nil
+}
+
+// buildCORS requires the cors section, so that allowing every origin is never implicit.
+func buildCORS(f *File) (negroni.Handler, error) {
+	if f.CORS == nil {
+		return nil, &KeyError{Key: "cors", Err: errors.New("the cors middleware requires the cors section")}
+	}
+
+	return middleware.NegroniCorsNew(cors.Options{
+		AllowedOrigins:   f.CORS.AllowedOrigins,
+		AllowedMethods:   f.CORS.AllowedMethods,
+		AllowedHeaders:   f.CORS.AllowedHeaders,
+		ExposedHeaders:   f.CORS.ExposedHeaders,
+		MaxAge:           f.CORS.MaxAge,
+		AllowCredentials: f.CORS.AllowCredentials,
+	}), nil
+}
+
+func buildJWT(f *File) (negroni.Handler, error) {
+	if f.JWT == nil {
+		return nil, &KeyError{Key: "jwt", Err: errors.New("the jwt middleware requires the jwt section")}
+	}
+
+	var method *jwt.SigningMethodHMAC
+	switch f.JWT.SigningMethod {
+	case "HS384":
+		method = jwt.SigningMethodHS384
+	case "HS512":
+		method = jwt.SigningMethodHS512
+	default:
+		method = jwt.SigningMethodHS256
+	}
+
+	skip := make(map[string]bool, len(f.JWT.SkipPaths))
+	for _, path := range f.JWT.SkipPaths {
+		skip[path] = true
+	}
+
+	skipper := func(path string) bool {
+		return skip[path]
+	}
+
+	return middleware.NegroniJwtHandler(f.JWT.Key, skipper, method, jwtErrHandler), nil
+}
+
+func jwtErrHandler(w http.ResponseWriter, r *http.Request, err string) {
+	http.Error(w, err, http.StatusUnauthorized)
+}
+
+func secureFactory(preset func() middleware.SecureOptions) MiddlewareFactory {
+	return func(_ *File) (negroni.Handler, error) {
+		return middleware.NegroniSecureHandler(preset(), nil), nil
+	}
+}
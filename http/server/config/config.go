@@ -0,0 +1,360 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/go-playground/validator.v9"
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultEnvPrefix is the prefix of the environment variables overriding the configuration file,
+// e.g. APIX_SERVER_ADDRESS overrides server.address.
+const DefaultEnvPrefix = "APIX"
+
+// File describes an Entrypoint and its middleware stack.
+type File struct {
+	Server Server `json:"server" yaml:"server" validate:"required"`
+	TLS    *TLS   `json:"tls" yaml:"tls"`
+	CORS   *CORS  `json:"cors" yaml:"cors"`
+	JWT    *JWT   `json:"jwt" yaml:"jwt"`
+	// TrustedProxies are the networks of the proxies trusted by the realip middleware.
+	TrustedProxies []string `json:"trusted_proxies" yaml:"trusted_proxies" validate:"dive,cidr|ip"`
+	Middlewares    []string `json:"middlewares" yaml:"middlewares" validate:"dive,required"`
+}
+
+// Server is the listening part of the configuration.
+type Server struct {
+	Address               string     `json:"address" yaml:"address"`
+	Listeners             []Listener `json:"listeners" yaml:"listeners" validate:"dive"`
+	ReadTimeout           Duration   `json:"read_timeout" yaml:"read_timeout" validate:"min=0"`
+	ReadHeaderTimeout     Duration   `json:"read_header_timeout" yaml:"read_header_timeout" validate:"min=0"`
+	WriteTimeout          Duration   `json:"write_timeout" yaml:"write_timeout" validate:"min=0"`
+	IdleTimeout           Duration   `json:"idle_timeout" yaml:"idle_timeout" validate:"min=0"`
+	MaxHeaderBytes        int        `json:"max_header_bytes" yaml:"max_header_bytes" validate:"min=0"`
+	MaxBodyBytes          int64      `json:"max_body_bytes" yaml:"max_body_bytes" validate:"min=0"`
+	DecompressBody        bool       `json:"decompress_body" yaml:"decompress_body"`
+	MaxDecompressionRatio int64      `json:"max_decompression_ratio" yaml:"max_decompression_ratio" validate:"min=0"`
+	DisableKeepAlives     bool       `json:"disable_keep_alives" yaml:"disable_keep_alives"`
+	MaxConnections        int        `json:"max_connections" yaml:"max_connections" validate:"min=0"`
+	ShutdownTimeout       Duration   `json:"shutdown_timeout" yaml:"shutdown_timeout" validate:"min=0"`
+	ShutdownDelay         Duration   `json:"shutdown_delay" yaml:"shutdown_delay" validate:"min=0"`
+	GracefulUpgrade       bool       `json:"graceful_upgrade" yaml:"graceful_upgrade"`
+	UpgradeTimeout        Duration   `json:"upgrade_timeout" yaml:"upgrade_timeout" validate:"min=0"`
+	HTTP2                 *HTTP2     `json:"http2" yaml:"http2"`
+	ProxyProtocol         *Proxy     `json:"proxy_protocol" yaml:"proxy_protocol"`
+	Admin                 *Admin     `json:"admin" yaml:"admin"`
+}
+
+// Admin enables the debugging listener, see server.AdminConfiguration.
+type Admin struct {
+	Network      string   `json:"network" yaml:"network" validate:"omitempty,eq=tcp|eq=tcp4|eq=tcp6|eq=unix"`
+	Address      string   `json:"address" yaml:"address" validate:"required"`
+	AllowedCIDRs []string `json:"allowed_cidrs" yaml:"allowed_cidrs" validate:"dive,cidr|ip"`
+	Username     string   `json:"username" yaml:"username"`
+	Password     string   `json:"password" yaml:"password"`
+}
+
+// Proxy enables the PROXY protocol, see server.ProxyProtocolConfiguration.
+type Proxy struct {
+	TrustedCIDRs  []string `json:"trusted_cidrs" yaml:"trusted_cidrs" validate:"dive,cidr|ip"`
+	HeaderTimeout Duration `json:"header_timeout" yaml:"header_timeout" validate:"min=0"`
+	Required      bool     `json:"required" yaml:"required"`
+}
+
+// HTTP2 tunes HTTP/2, see server.HTTP2Configuration.
+type HTTP2 struct {
+	H2C                  bool     `json:"h2c" yaml:"h2c"`
+	MaxConcurrentStreams uint32   `json:"max_concurrent_streams" yaml:"max_concurrent_streams"`
+	MaxReadFrameSize     uint32   `json:"max_read_frame_size" yaml:"max_read_frame_size" validate:"omitempty,min=16384,max=16777215"`
+	IdleTimeout          Duration `json:"idle_timeout" yaml:"idle_timeout" validate:"min=0"`
+}
+
+// Listener is a listening socket, see server.ListenerConfiguration.
+type Listener struct {
+	Network       string `json:"network" yaml:"network" validate:"omitempty,eq=tcp|eq=tcp4|eq=tcp6|eq=unix"`
+	Address       string `json:"address" yaml:"address" validate:"required"`
+	FileMode      string `json:"file_mode" yaml:"file_mode"`
+	TLS           *TLS   `json:"tls" yaml:"tls"`
+	RedirectPort  int    `json:"redirect_port" yaml:"redirect_port" validate:"min=0,max=65535"`
+	ProxyProtocol *Proxy `json:"proxy_protocol" yaml:"proxy_protocol"`
+}
+
+// TLS is the certificates and protocol settings of a https server.
+type TLS struct {
+	Cert           string        `json:"cert" yaml:"cert"`
+	Key            string        `json:"key" yaml:"key"`
+	Certificates   []Certificate `json:"certificates" yaml:"certificates" validate:"dive"`
+	ReloadInterval Duration      `json:"reload_interval" yaml:"reload_interval" validate:"min=0"`
+	MinVersion     string        `json:"min_version" yaml:"min_version" validate:"omitempty,eq=1.0|eq=1.1|eq=1.2|eq=1.3"`
+	CipherSuites   []string      `json:"cipher_suites" yaml:"cipher_suites"`
+	NextProtos     []string      `json:"next_protos" yaml:"next_protos"`
+	ClientCAs      []string      `json:"client_cas" yaml:"client_cas"`
+	ClientAuth     string        `json:"client_auth" yaml:"client_auth" validate:"omitempty,eq=none|eq=request|eq=require_any|eq=verify|eq=require"`
+}
+
+// Certificate is a certificate and private key pair.
+type Certificate struct {
+	Cert string `json:"cert" yaml:"cert" validate:"required"`
+	Key  string `json:"key" yaml:"key" validate:"required"`
+}
+
+// CORS mirrors cors.Options.
+type CORS struct {
+	AllowedOrigins   []string `json:"allowed_origins" yaml:"allowed_origins"`
+	AllowedMethods   []string `json:"allowed_methods" yaml:"allowed_methods"`
+	AllowedHeaders   []string `json:"allowed_headers" yaml:"allowed_headers"`
+	ExposedHeaders   []string `json:"exposed_headers" yaml:"exposed_headers"`
+	MaxAge           int      `json:"max_age" yaml:"max_age" validate:"min=0"`
+	AllowCredentials bool     `json:"allow_credentials" yaml:"allow_credentials"`
+}
+
+// JWT configures the JWT middleware.
+type JWT struct {
+	Key           string   `json:"key" yaml:"key" validate:"required"`
+	SigningMethod string   `json:"signing_method" yaml:"signing_method" validate:"omitempty,eq=HS256|eq=HS384|eq=HS512"`
+	SkipPaths     []string `json:"skip_paths" yaml:"skip_paths"`
+}
+
+// Duration is a time.Duration written as a string such as "10s" in configuration files.
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string, a number is taken as nanoseconds.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		var n int64
+		if err := json.Unmarshal(b, &n); err != nil {
+			return fmt.Errorf("invalid duration %s", b)
+		}
+		*d = Duration(n)
+		return nil
+	}
+
+	return d.set(s)
+}
+
+// UnmarshalText parses a duration string, such as a YAML scalar. An integer is taken as
+// nanoseconds.
+func (d *Duration) UnmarshalText(b []byte) error {
+	if n, err := strconv.ParseInt(string(b), 10, 64); err == nil {
+		*d = Duration(n)
+		return nil
+	}
+
+	return d.set(string(b))
+}
+
+// MarshalJSON writes the duration as a string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// MarshalText writes the duration as a string.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) set(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q", s)
+	}
+
+	*d = Duration(v)
+	return nil
+}
+
+// KeyError reports a problem with a single key of the configuration.
+type KeyError struct {
+	Key string
+	Err error
+}
+
+func (e *KeyError) Error() string {
+	return fmt.Sprintf("config: key %q: %v", e.Key, e.Err)
+}
+
+// Errors is the list of problems found while validating a configuration.
+type Errors []*KeyError
+
+func (es Errors) Error() string {
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Decoder decodes the content of a configuration file into v.
+type Decoder func(data []byte, v interface{}) error
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = map[string]Decoder{
+		".json": decodeJSON,
+		".yaml": yaml.UnmarshalStrict,
+		".yml":  yaml.UnmarshalStrict,
+	}
+)
+
+// decodeJSON decodes a JSON configuration, rejecting the unknown keys.
+func decodeJSON(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// RegisterDecoder registers the decoder of the files with extension ext, e.g. ".toml".
+// JSON and YAML are supported out of the box, and reject the keys unknown to File.
+func RegisterDecoder(ext string, dec Decoder) {
+	decodersMu.Lock()
+	decoders[strings.ToLower(ext)] = dec
+	decodersMu.Unlock()
+}
+
+// Load reads the configuration file at path, applies the environment overrides with
+// DefaultEnvPrefix and validates the result.
+func Load(path string) (*File, error) {
+	return LoadWithPrefix(path, DefaultEnvPrefix)
+}
+
+// LoadWithPrefix is like Load but reads the environment variables starting with prefix.
+// An empty prefix disables the overrides.
+func LoadWithPrefix(path, prefix string) (*File, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	decodersMu.RLock()
+	dec, ok := decoders[ext]
+	decodersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("config: no decoder registered for %q files", ext)
+	}
+
+	f := &File{}
+	if err = dec(data, f); err != nil {
+		return nil, decodeError(err)
+	}
+
+	if prefix != "" {
+		if err = applyEnv(f, prefix); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = f.Validate(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// unknownFieldPrefix starts the error of encoding/json on an unknown key.
+const unknownFieldPrefix = "json: unknown field "
+
+func decodeError(err error) error {
+	if e, ok := err.(*json.UnmarshalTypeError); ok && e.Field != "" {
+		return &KeyError{Key: e.Field, Err: fmt.Errorf("cannot use %s as %s", e.Value, e.Type)}
+	}
+	if msg := err.Error(); strings.HasPrefix(msg, unknownFieldPrefix) {
+		if key, uerr := strconv.Unquote(strings.TrimPrefix(msg, unknownFieldPrefix)); uerr == nil {
+			return &KeyError{Key: key, Err: errors.New("unknown key")}
+		}
+	}
+	return fmt.Errorf("config: %v", err)
+}
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return keyName(field)
+	})
+	return v
+}
+
+// Validate checks the configuration, the errors carry the offending keys.
+func (f *File) Validate() error {
+	var errs Errors
+
+	if err := validate.Struct(f); err != nil {
+		verrs, ok := err.(validator.ValidationErrors)
+		if !ok {
+			return err
+		}
+
+		for _, fe := range verrs {
+			key := fe.Namespace()
+			if i := strings.Index(key, "."); i >= 0 {
+				key = key[i+1:]
+			}
+			errs = append(errs, &KeyError{Key: key, Err: fmt.Errorf("failed on the %q rule", fe.Tag())})
+		}
+	}
+
+	if f.Server.Address == "" && len(f.Server.Listeners) == 0 {
+		errs = append(errs, &KeyError{Key: "server.address", Err: errors.New("address or listeners is required")})
+	}
+
+	if f.TLS != nil {
+		errs = append(errs, f.TLS.validate("tls")...)
+	}
+
+	for i, l := range f.Server.Listeners {
+		key := fmt.Sprintf("server.listeners[%d]", i)
+		if l.FileMode != "" {
+			if _, err := strconv.ParseUint(l.FileMode, 8, 32); err != nil {
+				errs = append(errs, &KeyError{Key: key + ".file_mode", Err: fmt.Errorf("invalid octal mode %q", l.FileMode)})
+			}
+		}
+		if l.TLS != nil {
+			errs = append(errs, l.TLS.validate(key+".tls")...)
+		}
+	}
+
+	if a := f.Server.Admin; a != nil && a.Username != "" && a.Password == "" {
+		errs = append(errs, &KeyError{Key: "server.admin.password", Err: errors.New("password is required with a username")})
+	}
+
+	for i, name := range f.Middlewares {
+		if _, ok := lookupMiddleware(name); !ok {
+			errs = append(errs, &KeyError{Key: fmt.Sprintf("middlewares[%d]", i), Err: fmt.Errorf("unknown middleware %q", name)})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// keyName returns the configuration key of a struct field.
+func keyName(field reflect.StructField) string {
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
@@ -0,0 +1,140 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	return writeConfigFile(t, "config.json", content)
+}
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	dir, err := ioutil.TempDir("", "apix-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, name)
+	if err = ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	path := writeConfig(t, `{
+		"server": {"address": ":8080", "read_timeout": "5s"},
+		"jwt": {"key": "secret"},
+		"middlewares": ["recover", "logger", "jwt"]
+	}`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	os.Setenv("APIXTEST_SERVER_ADDRESS", ":9090")
+	os.Setenv("APIXTEST_CORS_ALLOWED_ORIGINS", "a.com, b.com")
+	defer os.Unsetenv("APIXTEST_SERVER_ADDRESS")
+	defer os.Unsetenv("APIXTEST_CORS_ALLOWED_ORIGINS")
+
+	f, err := LoadWithPrefix(path, "APIXTEST")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if f.Server.Address != ":9090" || time.Duration(f.Server.ReadTimeout) != 5*time.Second {
+		t.Fatalf("unexpected server %+v", f.Server)
+	}
+	if f.CORS == nil || len(f.CORS.AllowedOrigins) != 2 {
+		t.Fatalf("unexpected cors %+v", f.CORS)
+	}
+
+	if _, err = NewEntrypoint(f); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewEntrypointMissingSection(t *testing.T) {
+	for _, name := range []string{"cors", "jwt"} {
+		path := writeConfig(t, `{"server": {"address": ":8080"}, "middlewares": ["recover", "`+name+`"]}`)
+		defer os.RemoveAll(filepath.Dir(path))
+
+		f, err := LoadWithPrefix(path, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = NewEntrypoint(f)
+		if ke, ok := err.(*KeyError); !ok || ke.Key != name {
+			t.Fatalf("%s: the missing section was not reported: %v", name, err)
+		}
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	path := writeConfig(t, `{
+		"server": {},
+		"jwt": {"key": "secret", "signing_method": "RS256"},
+		"middlewares": ["recover", "unknown"]
+	}`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	_, err := LoadWithPrefix(path, "")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	for _, key := range []string{"server.address", "jwt.signing_method", "middlewares[1]"} {
+		if !strings.Contains(err.Error(), `"`+key+`"`) {
+			t.Errorf("error does not point at %s: %v", key, err)
+		}
+	}
+}
+
+func TestLoadYAML(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", `
+server:
+  address: ":8080"
+  read_timeout: 5s
+  shutdown_timeout: 30s
+  listeners:
+    - address: ":8443"
+      redirect_port: 443
+middlewares: [recover, logger]
+`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	f, err := LoadWithPrefix(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if time.Duration(f.Server.ReadTimeout) != 5*time.Second || time.Duration(f.Server.ShutdownTimeout) != 30*time.Second {
+		t.Fatalf("unexpected durations %+v", f.Server)
+	}
+	if len(f.Server.Listeners) != 1 || f.Server.Listeners[0].RedirectPort != 443 || len(f.Middlewares) != 2 {
+		t.Fatalf("unexpected configuration %+v", f)
+	}
+}
+
+func TestLoadUnknownKey(t *testing.T) {
+	for name, content := range map[string]string{
+		"config.json": `{"server": {"address": ":8080", "write_timout": "5s"}}`,
+		"config.yml":  "server:\n  address: \":8080\"\n  write_timout: 5s\n",
+	} {
+		path := writeConfigFile(t, name, content)
+		defer os.RemoveAll(filepath.Dir(path))
+
+		_, err := LoadWithPrefix(path, "")
+		if err == nil || !strings.Contains(err.Error(), "write_timout") {
+			t.Fatalf("%s: the unknown key was not reported: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var durationType = reflect.TypeOf(Duration(0))
+
+// applyEnv overrides the fields of f by the environment variables named after their keys,
+// e.g. APIX_SERVER_READ_TIMEOUT for server.read_timeout. Lists are comma separated.
+func applyEnv(f *File, prefix string) error {
+	return walkEnv(reflect.ValueOf(f).Elem(), strings.ToUpper(prefix), "")
+}
+
+func walkEnv(v reflect.Value, env, key string) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := keyName(field)
+		if name == "" {
+			continue
+		}
+
+		fieldEnv := env + "_" + strings.ToUpper(name)
+		fieldKey := name
+		if key != "" {
+			fieldKey = key + "." + name
+		}
+
+		fv := v.Field(i)
+		ft := field.Type
+
+		if ft.Kind() == reflect.Ptr && ft.Elem().Kind() == reflect.Struct {
+			if fv.IsNil() {
+				if !hasEnvPrefix(fieldEnv + "_") {
+					continue
+				}
+				fv.Set(reflect.New(ft.Elem()))
+			}
+			if err := walkEnv(fv.Elem(), fieldEnv, fieldKey); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if ft.Kind() == reflect.Struct {
+			if err := walkEnv(fv, fieldEnv, fieldKey); err != nil {
+				return err
+			}
+			continue
+		}
+
+		s, ok := os.LookupEnv(fieldEnv)
+		if !ok {
+			continue
+		}
+
+		if err := setValue(fv, s); err != nil {
+			return &KeyError{Key: fieldKey, Err: fmt.Errorf("invalid value %q from %s: %v", s, fieldEnv, err)}
+		}
+	}
+
+	return nil
+}
+
+func hasEnvPrefix(prefix string) bool {
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func setValue(v reflect.Value, s string) error {
+	if v.Type() == durationType {
+		var d Duration
+		if err := d.set(s); err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
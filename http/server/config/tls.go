@@ -0,0 +1,59 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package config
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"none":        tls.NoClientCert,
+	"request":     tls.RequestClientCert,
+	"require_any": tls.RequireAnyClientCert,
+	"verify":      tls.VerifyClientCertIfGiven,
+	"require":     tls.RequireAndVerifyClientCert,
+}
+
+func cipherSuite(name string) (uint16, bool) {
+	for _, suite := range tls.CipherSuites() {
+		if suite.Name == name {
+			return suite.ID, true
+		}
+	}
+	return 0, false
+}
+
+func (t *TLS) validate(key string) Errors {
+	var errs Errors
+
+	if (t.Cert == "") != (t.Key == "") {
+		errs = append(errs, &KeyError{Key: key + ".cert", Err: errors.New("cert and key must be set together")})
+	}
+	if t.Cert == "" && len(t.Certificates) == 0 {
+		errs = append(errs, &KeyError{Key: key + ".certificates", Err: errors.New("at least one certificate is required")})
+	}
+
+	if clientAuthTypes[t.ClientAuth] >= tls.VerifyClientCertIfGiven && len(t.ClientCAs) == 0 {
+		errs = append(errs, &KeyError{Key: key + ".client_cas", Err: errors.New("client certificate verification requires client CAs")})
+	}
+
+	for i, name := range t.CipherSuites {
+		if _, ok := cipherSuite(name); !ok {
+			errs = append(errs, &KeyError{Key: fmt.Sprintf("%s.cipher_suites[%d]", key, i), Err: fmt.Errorf("unknown cipher suite %q", name)})
+		}
+	}
+
+	return errs
+}
@@ -5,13 +5,130 @@
 
 package server
 
+import (
+	"crypto/tls"
+	"os"
+	"time"
+)
+
 // Configuration for a http server.
+// A zero value of a timeout or a limit means there is none.
 type Configuration struct {
+	// Address is the TCP address of the server, served with the TLS configuration of the
+	// Entrypoint. It is ignored if Listeners is not empty.
 	Address string
+
+	// Listeners are the sockets the server listens on, they share the router, the
+	// middlewares and the lifecycle of the Entrypoint.
+	Listeners []ListenerConfiguration
+
+	// ReadTimeout is the maximum duration for reading the entire request, including the body.
+	ReadTimeout time.Duration
+	// ReadHeaderTimeout is the amount of time allowed to read request headers.
+	ReadHeaderTimeout time.Duration
+	// WriteTimeout is the maximum duration before timing out writes of the response.
+	WriteTimeout time.Duration
+	// IdleTimeout is the maximum amount of time to wait for the next request when keep-alives are enabled.
+	IdleTimeout time.Duration
+	// MaxHeaderBytes is the maximum size of request headers, net/http uses 1 MB if it is zero.
+	MaxHeaderBytes int
+	// MaxBodyBytes limits the size of every request body after decompression, routes
+	// change it with Route.LimitBody or the BodyLimit filter.
+	MaxBodyBytes int64
+	// DecompressBody decodes the gzip and deflate request bodies for the handlers.
+	DecompressBody bool
+	// MaxDecompressionRatio is the largest ratio of a decompressed request body to its
+	// compressed size, 100 if it is zero.
+	MaxDecompressionRatio int64
+	// DisableKeepAlives closes the connection after each request.
+	DisableKeepAlives bool
+	// MaxConnections limits the number of simultaneous connections accepted.
+	MaxConnections int
+
+	// ShutdownTimeout is the maximum duration to drain the connections on Stop, 3 seconds if it is zero.
+	ShutdownTimeout time.Duration
+	// ShutdownDelay is the time the entrypoint reports draining before the listener closes,
+	// so that load balancers stop routing to it.
+	ShutdownDelay time.Duration
+
+	// GracefulUpgrade enables the binary upgrade on SIGUSR2 or SIGHUP: a new process
+	// inherits the listening sockets and this one drains once the new one is ready.
+	GracefulUpgrade bool
+	// UpgradeTimeout is the maximum time to wait for the upgraded process, 30 seconds if it is zero.
+	UpgradeTimeout time.Duration
+
+	// HTTP2 tunes HTTP/2 and enables cleartext HTTP/2, the net/http defaults apply if it is nil.
+	HTTP2 *HTTP2Configuration
+
+	// ProxyProtocol parses the PROXY protocol headers on all listeners if it is not nil.
+	ProxyProtocol *ProxyProtocolConfiguration
+
+	// Admin serves pprof, expvar and the route table on a separate listener if it is not nil,
+	// the package github.com/TechCatsLab/apix/http/server/admin must be imported.
+	Admin *AdminConfiguration
+}
+
+// DefaultConfiguration returns a Configuration listening on address with conservative
+// timeouts and limits.
+func DefaultConfiguration(address string) *Configuration {
+	return &Configuration{
+		Address:           address,
+		ReadTimeout:       30 * time.Second,
+		ReadHeaderTimeout: 10 * time.Second,
+		WriteTimeout:      60 * time.Second,
+		IdleTimeout:       120 * time.Second,
+		MaxHeaderBytes:    1 << 20,
+		MaxBodyBytes:      10 << 20,
+		ShutdownTimeout:   defaultShutdownTimeout,
+	}
+}
+
+// ListenerConfiguration describes a listening socket of an Entrypoint.
+type ListenerConfiguration struct {
+	// Network is one of "tcp", "tcp4", "tcp6" and "unix", "tcp" if it is empty.
+	Network string
+	// Address is a host:port pair, or a socket path for unix.
+	Address string
+	// FileMode is the permission of a unix socket file, the umask applies if it is zero.
+	FileMode os.FileMode
+	// TLS serves https on the listener if it is not nil.
+	TLS *TLSConfiguration
+	// RedirectPort makes the listener redirect every request to https on this port
+	// instead of serving the router, e.g. 443.
+	RedirectPort int
+	// ProxyProtocol overrides Configuration.ProxyProtocol for the listener.
+	ProxyProtocol *ProxyProtocolConfiguration
 }
 
 // TLSConfiguration is the configuration for a https server.
 type TLSConfiguration struct {
 	Key  string
 	Cert string
+
+	// Certificates are additional key pairs, selected by SNI. Key and Cert, if set,
+	// is the default certificate, otherwise the first of Certificates is.
+	Certificates []CertificateConfiguration
+
+	// ReloadInterval is the interval to check the key pairs for changes, 0 disables it.
+	// Certificates are also reloaded by Entrypoint.ReloadCertificates and on SIGUSR1.
+	ReloadInterval time.Duration
+
+	// MinVersion is the minimum TLS version, such as tls.VersionTLS12, TLS 1.2 if it is zero.
+	MinVersion uint16
+	// CipherSuites are the enabled TLS 1.0-1.2 cipher suites, the Go defaults if it is empty.
+	CipherSuites []uint16
+	// NextProtos are the ALPN protocols, "h2" and "http/1.1" if it is empty.
+	NextProtos []string
+
+	// ClientCAs are the PEM files of the authorities verifying client certificates.
+	ClientCAs []string
+	// ClientAuth is the client certificate policy, such as tls.RequireAndVerifyClientCert.
+	// tls.VerifyClientCertIfGiven lets routes enforce it with ClientCertificateFilter.
+	ClientAuth tls.ClientAuthType
+}
+
+// CertificateConfiguration is a certificate and private key pair.
+type CertificateConfiguration struct {
+	Key  string
+	Cert string
 }
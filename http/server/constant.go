@@ -25,15 +25,17 @@ const (
 	MIMEMultipartForm              = "multipart/form-data"
 
 	// Headers
-	HeaderOrigin        = "Origin"
-	HeaderAccept        = "Accept"
-	HeaderVary          = "Vary"
-	HeaderCookie        = "Cookie"
-	HeaderSetCookie     = "Set-Cookie"
-	HeaderUpgrade       = "Upgrade"
-	HeaderContentType   = "Content-Type"
-	HeaderLocation      = "Location"
-	HeaderAuthorization = "Authorization"
+	HeaderOrigin          = "Origin"
+	HeaderAccept          = "Accept"
+	HeaderVary            = "Vary"
+	HeaderCookie          = "Cookie"
+	HeaderSetCookie       = "Set-Cookie"
+	HeaderUpgrade         = "Upgrade"
+	HeaderContentType     = "Content-Type"
+	HeaderContentLength   = "Content-Length"
+	HeaderContentEncoding = "Content-Encoding"
+	HeaderLocation        = "Location"
+	HeaderAuthorization   = "Authorization"
 
 	// Access control
 	HeaderAccessControlRequestMethod    = "Access-Control-Request-Method"
@@ -44,4 +46,12 @@ const (
 	HeaderAccessControlExposeHeaders    = "Access-Control-Expose-Headers"
 	HeaderAccessControlAllowCredentials = "Access-Control-Allow-Credentials"
 	HeaderAccessControlMaxAge           = "Access-Control-Max-Age"
+
+	// Security
+	HeaderStrictTransportSecurity = "Strict-Transport-Security"
+	HeaderContentSecurityPolicy   = "Content-Security-Policy"
+	HeaderXContentTypeOptions     = "X-Content-Type-Options"
+	HeaderXFrameOptions           = "X-Frame-Options"
+	HeaderReferrerPolicy          = "Referrer-Policy"
+	HeaderPermissionsPolicy       = "Permissions-Policy"
 )
\ No newline at end of file
@@ -6,7 +6,9 @@
 package server
 
 import (
+	stdjson "encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"net/url"
 	"strings"
@@ -21,6 +23,7 @@ var (
 	errNoBody              = errors.New("request body is empty")
 	errEmptyResponse       = errors.New("empty JSON response body")
 	errNotJSONBody         = errors.New("request body is not JSON")
+	errTrailingJSON        = errors.New("request body has data after the JSON value")
 	errInvalidRedirectCode = errors.New("invalid redirect status code")
 )
 
@@ -32,6 +35,7 @@ type Context struct {
 	Validator      *validator.Validate
 	LastError      error
 	store          map[string]interface{}
+	jsonOptions    JSONOptions
 }
 
 // NewContext create a new context.
@@ -60,9 +64,23 @@ func isJson(s string) bool {
 	return strings.EqualFold(s, j) || strings.EqualFold(s, jsonCharset)
 }
 
-// JSONBody parses the JSON request body.
+// JSONOptions makes the decoding of JSON request bodies strict.
+type JSONOptions struct {
+	// DisallowUnknownFields rejects an object with a key matching no field of the struct.
+	DisallowUnknownFields bool
+	// SingleValue rejects a body with anything but spaces after the JSON value.
+	SingleValue bool
+}
+
+// JSONBody parses the JSON request body, with the JSONOptions of the Router.
 func (c *Context) JSONBody(v interface{}) error {
-	if c.request.ContentLength == 0 {
+	return c.JSONBodyWith(v, c.jsonOptions)
+}
+
+// JSONBodyWith parses the JSON request body with opt. A body over its limit returns
+// ErrBodyTooLarge, and an empty body, with or without a length, errNoBody.
+func (c *Context) JSONBodyWith(v interface{}, opt JSONOptions) error {
+	if c.request.ContentLength == 0 || c.request.Body == nil || c.request.Body == http.NoBody {
 		return errNoBody
 	}
 
@@ -70,7 +88,41 @@ func (c *Context) JSONBody(v interface{}) error {
 		return errNotJSONBody
 	}
 
-	return json.NewDecoder(c.request.Body).Decode(v)
+	body := &countingReader{r: c.request.Body}
+
+	var err error
+	if opt.DisallowUnknownFields || opt.SingleValue {
+		err = decodeStrictJSON(body, v, opt)
+	} else {
+		err = json.NewDecoder(body).Decode(v)
+	}
+
+	if bodyErr := bodyError(c.request); bodyErr != nil {
+		return bodyErr
+	}
+	if err == io.EOF || (err != nil && body.n == 0) {
+		return errNoBody
+	}
+	return err
+}
+
+// decodeStrictJSON decodes with encoding/json, json-iterator does not reject unknown fields.
+func decodeStrictJSON(r io.Reader, v interface{}, opt JSONOptions) error {
+	dec := stdjson.NewDecoder(r)
+	if opt.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+
+	if opt.SingleValue {
+		if _, err := dec.Token(); err != io.EOF {
+			return errTrailingJSON
+		}
+	}
+	return nil
 }
 
 // ServeJSON sends a JSON response.
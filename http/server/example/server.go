@@ -16,7 +16,7 @@ import (
 const privateTokenKey = "your_token_key"
 
 func main() {
-	config := &server.Configuration{":3355"}
+	config := server.DefaultConfiguration(":3355")
 	ep := server.NewEntrypoint(config, nil)
 
 	ep.AttachMiddleware(middleware.NegroniRecoverHandler())
@@ -28,9 +28,9 @@ func main() {
 	router.Get("/", handle)
 	router.Post("/test", handle)
 
-	ep.Start(router.Handler())
-
-	ep.Run()
+	if err := ep.ListenAndServe(router.Handler()); err != nil {
+		fmt.Println(err)
+	}
 }
 
 func handle(ctx *server.Context) error {
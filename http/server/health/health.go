@@ -0,0 +1,315 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+// Package health serves Kubernetes style liveness and readiness probes from named checks.
+//
+// The clients of apix provide a Ping method to be registered as a check:
+//
+//	h := health.New()
+//	h.Register(health.Check{Name: "nsq", Func: nsqClient.Ping})
+//	h.Register(health.Check{Name: "geoip2", Func: geoClient.Ping, Severity: health.NonCritical})
+//	h.Register(health.Check{Name: "cos", Func: bucketClient.Ping, CacheTTL: 10 * time.Second})
+//	h.Bind(ep)
+//	h.Mount(router)
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/TechCatsLab/apix/http/server"
+)
+
+// Paths of the probes mounted by Mount.
+const (
+	LivenessPath  = "/healthz"
+	ReadinessPath = "/readyz"
+
+	defaultTimeout = 5 * time.Second
+)
+
+// Status of a check or of a probe.
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+var (
+	errNoName      = errors.New("health: check has no name")
+	errNoFunc      = errors.New("health: check has no func")
+	errNotReady    = errors.New("entrypoint is not serving")
+	errDuplication = errors.New("health: check is already registered")
+)
+
+// Severity tells whether a failing check fails the probe.
+type Severity int
+
+const (
+	// Critical checks fail the probe when they fail.
+	Critical Severity = iota
+	// NonCritical checks are reported but do not fail the probe.
+	NonCritical
+)
+
+// CheckFunc reports whether a dependency is healthy, ctx is cancelled on timeout.
+type CheckFunc func(ctx context.Context) error
+
+// Check is a named health check.
+type Check struct {
+	Name string
+	Func CheckFunc
+	// Timeout limits a run of Func, 5 seconds if it is zero.
+	Timeout time.Duration
+	// CacheTTL reuses the last result for the duration, Func runs on every probe if it is zero.
+	CacheTTL time.Duration
+	Severity Severity
+	// Liveness also runs the check for the liveness probe, only the readiness probe runs it
+	// otherwise. A failing dependency should rarely get the process restarted.
+	Liveness bool
+}
+
+// Result is the outcome of a check.
+type Result struct {
+	Status   string    `json:"status"`
+	Error    string    `json:"error,omitempty"`
+	Critical bool      `json:"critical"`
+	Duration string    `json:"duration"`
+	Time     time.Time `json:"time"`
+}
+
+// Report is the response of a probe.
+type Report struct {
+	Status string             `json:"status"`
+	Checks map[string]*Result `json:"checks,omitempty"`
+}
+
+type check struct {
+	Check
+	mu       sync.Mutex
+	result   *Result
+	inflight *checkCall
+}
+
+// checkCall is a run of a check shared by the concurrent probes.
+type checkCall struct {
+	done   chan struct{}
+	result *Result
+}
+
+// Health holds the checks of the probes.
+type Health struct {
+	mu     sync.RWMutex
+	checks []*check
+	ready  func() bool
+}
+
+// New creates a Health without checks.
+func New() *Health {
+	return &Health{}
+}
+
+// Register adds a check, names are unique.
+func (h *Health) Register(c Check) error {
+	if c.Name == "" {
+		return errNoName
+	}
+	if c.Func == nil {
+		return errNoFunc
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, registered := range h.checks {
+		if registered.Name == c.Name {
+			return fmt.Errorf("%v: %s", errDuplication, c.Name)
+		}
+	}
+
+	h.checks = append(h.checks, &check{Check: c})
+	return nil
+}
+
+// SetReadiness sets the function gating the readiness probe before the checks run.
+func (h *Health) SetReadiness(ready func() bool) {
+	h.mu.Lock()
+	h.ready = ready
+	h.mu.Unlock()
+}
+
+// Bind ties the readiness probe to ep, it fails until ep serves and once ep drains, so
+// that load balancers stop sending requests before the listeners close.
+func (h *Health) Bind(ep *server.Entrypoint) {
+	h.SetReadiness(ep.Ready)
+}
+
+// Mount registers the liveness and the readiness probes on rt.
+func (h *Health) Mount(rt *server.Router) {
+	rt.Get(LivenessPath, h.Liveness)
+	rt.Get(ReadinessPath, h.Readiness)
+}
+
+// Liveness serves the liveness probe, it runs the checks marked Liveness.
+func (h *Health) Liveness(c *server.Context) error {
+	report := h.Run(c.Request().Context(), true)
+	return serveReport(c, report)
+}
+
+// Readiness serves the readiness probe, it runs every check.
+func (h *Health) Readiness(c *server.Context) error {
+	h.mu.RLock()
+	ready := h.ready
+	h.mu.RUnlock()
+
+	if ready != nil && !ready() {
+		return serveReport(c, &Report{
+			Status: StatusFail,
+			Checks: map[string]*Result{
+				"entrypoint": {Status: StatusFail, Error: errNotReady.Error(), Critical: true, Time: time.Now()},
+			},
+		})
+	}
+
+	report := h.Run(c.Request().Context(), false)
+	return serveReport(c, report)
+}
+
+// Run runs the checks concurrently, only those marked Liveness if liveness is true.
+func (h *Health) Run(ctx context.Context, liveness bool) *Report {
+	h.mu.RLock()
+	checks := make([]*check, 0, len(h.checks))
+	for _, c := range h.checks {
+		if !liveness || c.Liveness {
+			checks = append(checks, c)
+		}
+	}
+	h.mu.RUnlock()
+
+	results := make([]*Result, len(checks))
+
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			results[i] = c.run(ctx)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := &Report{
+		Status: StatusOK,
+		Checks: make(map[string]*Result, len(checks)),
+	}
+	for i, c := range checks {
+		report.Checks[c.Name] = results[i]
+		if results[i].Status == StatusFail && c.Severity == Critical {
+			report.Status = StatusFail
+		}
+	}
+
+	return report
+}
+
+// run returns the cached result if it is fresh, concurrent probes share a single run. A
+// probe gone before the run completes gets a failure, the run goes on for the others.
+func (c *check) run(ctx context.Context) *Result {
+	c.mu.Lock()
+	if c.result != nil && c.CacheTTL > 0 && time.Since(c.result.Time) < c.CacheTTL {
+		result := c.result
+		c.mu.Unlock()
+		return result
+	}
+
+	call := c.inflight
+	if call == nil {
+		call = &checkCall{done: make(chan struct{})}
+		c.inflight = call
+		go c.execute(call)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.result
+	case <-ctx.Done():
+		return &Result{
+			Status:   StatusFail,
+			Error:    ctx.Err().Error(),
+			Critical: c.Severity == Critical,
+			Time:     time.Now(),
+		}
+	}
+}
+
+// execute runs the check within its timeout, independently of the probes waiting for it.
+func (c *check) execute(call *checkCall) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("check panicked: %v", r)
+			}
+		}()
+		done <- c.Func(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := &Result{
+		Status:   StatusOK,
+		Critical: c.Severity == Critical,
+		Duration: time.Since(start).String(),
+		Time:     start,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+
+	c.mu.Lock()
+	c.result = result
+	c.inflight = nil
+	c.mu.Unlock()
+
+	call.result = result
+	close(call.done)
+}
+
+func serveReport(c *server.Context, report *Report) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	if report.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	w := c.Response()
+	w.Header().Set(server.HeaderContentType, server.MIMEApplicationJSONCharsetUTF8)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_, err = w.Write(body)
+	return err
+}
@@ -0,0 +1,163 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/TechCatsLab/apix/http/server"
+)
+
+func probe(t *testing.T, h *Health, path string) (int, *Report) {
+	rt := server.NewRouter()
+	h.Mount(rt)
+
+	rec := httptest.NewRecorder()
+	rt.Handler().ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+
+	var report Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	return rec.Code, &report
+}
+
+func TestSeverity(t *testing.T) {
+	h := New()
+	h.Register(Check{Name: "db", Func: func(context.Context) error { return nil }})
+	h.Register(Check{Name: "geoip2", Func: func(context.Context) error { return errors.New("no database") }, Severity: NonCritical})
+
+	code, report := probe(t, h, ReadinessPath)
+	if code != http.StatusOK || report.Status != StatusOK {
+		t.Fatal("a non critical failure failed the probe:", code, report.Status)
+	}
+	if report.Checks["geoip2"].Error != "no database" {
+		t.Fatal("unexpected report:", report.Checks["geoip2"])
+	}
+
+	h.Register(Check{Name: "nsq", Func: func(context.Context) error { return errors.New("refused") }})
+
+	code, report = probe(t, h, ReadinessPath)
+	if code != http.StatusServiceUnavailable || report.Status != StatusFail {
+		t.Fatal("a critical failure did not fail the probe:", code, report.Status)
+	}
+}
+
+func TestLiveness(t *testing.T) {
+	h := New()
+	h.Register(Check{Name: "nsq", Func: func(context.Context) error { return errors.New("refused") }})
+	h.Register(Check{Name: "self", Func: func(context.Context) error { return nil }, Liveness: true})
+
+	code, report := probe(t, h, LivenessPath)
+	if code != http.StatusOK || len(report.Checks) != 1 || report.Checks["self"] == nil {
+		t.Fatal("unexpected liveness report:", code, report.Checks)
+	}
+}
+
+func TestReadiness(t *testing.T) {
+	var ready int32
+
+	h := New()
+	h.SetReadiness(func() bool { return atomic.LoadInt32(&ready) == 1 })
+
+	if code, _ := probe(t, h, ReadinessPath); code != http.StatusServiceUnavailable {
+		t.Fatal("ready before serving:", code)
+	}
+
+	atomic.StoreInt32(&ready, 1)
+	if code, _ := probe(t, h, ReadinessPath); code != http.StatusOK {
+		t.Fatal("not ready while serving:", code)
+	}
+}
+
+func TestTimeoutAndCache(t *testing.T) {
+	var runs int32
+
+	h := New()
+	h.Register(Check{
+		Name:     "slow",
+		Timeout:  20 * time.Millisecond,
+		CacheTTL: time.Minute,
+		Func: func(ctx context.Context) error {
+			atomic.AddInt32(&runs, 1)
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+
+	for i := 0; i < 3; i++ {
+		report := h.Run(context.Background(), false)
+		if report.Checks["slow"].Error != context.DeadlineExceeded.Error() {
+			t.Fatal("the check did not time out:", report.Checks["slow"])
+		}
+	}
+
+	if n := atomic.LoadInt32(&runs); n != 1 {
+		t.Fatal("the cached result was not reused, runs:", n)
+	}
+}
+
+func TestConcurrentProbes(t *testing.T) {
+	var runs int32
+	entered := make(chan struct{})
+	release := make(chan struct{})
+
+	h := New()
+	h.Register(Check{
+		Name:    "db",
+		Timeout: time.Minute,
+		Func: func(ctx context.Context) error {
+			atomic.AddInt32(&runs, 1)
+			close(entered)
+			<-release
+			return nil
+		},
+	})
+
+	first := make(chan *Report)
+	go func() {
+		first <- h.Run(context.Background(), false)
+	}()
+	<-entered
+
+	// The probes arriving during the run join it, and give up with their context instead
+	// of queueing behind it.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 3; i++ {
+		if report := h.Run(ctx, false); report.Status != StatusFail || report.Checks["db"].Error != context.Canceled.Error() {
+			t.Fatal("unexpected report", report.Checks["db"])
+		}
+	}
+
+	close(release)
+	if report := <-first; report.Status != StatusOK {
+		t.Fatal("unexpected report", report.Checks["db"])
+	}
+	if n := atomic.LoadInt32(&runs); n != 1 {
+		t.Fatal("the probes did not share the run, runs:", n)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	h := New()
+	if err := h.Register(Check{Name: "db"}); err != errNoFunc {
+		t.Fatal("unexpected error:", err)
+	}
+	if err := h.Register(Check{Name: "db", Func: func(context.Context) error { return nil }}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Register(Check{Name: "db", Func: func(context.Context) error { return nil }}); err == nil {
+		t.Fatal("a duplicated check was registered")
+	}
+}
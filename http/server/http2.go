@@ -0,0 +1,391 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+const (
+	// maxUpgradeBodyBytes is the largest request body an Upgrade: h2c request may carry,
+	// larger requests are served over HTTP/1.1.
+	maxUpgradeBodyBytes = 64 << 10
+
+	h2cPreface     = http2.ClientPreface
+	h2cPrefaceTail = "SM\r\n\r\n"
+)
+
+var errBadSettings = errors.New("invalid HTTP2-Settings header")
+
+// HTTP2Configuration tunes the HTTP/2 servers of an Entrypoint. HTTP/2 is negotiated by
+// ALPN on TLS listeners, H2C enables it on the plain ones.
+type HTTP2Configuration struct {
+	// H2C serves cleartext HTTP/2 to clients with prior knowledge or using Upgrade: h2c.
+	H2C bool
+	// MaxConcurrentStreams is the number of concurrent streams per connection, 250 if it is zero.
+	MaxConcurrentStreams uint32
+	// MaxReadFrameSize is the largest frame the server reads, 1 MB if it is zero.
+	MaxReadFrameSize uint32
+	// IdleTimeout closes connections without streams, Configuration.IdleTimeout if it is zero.
+	IdleTimeout time.Duration
+}
+
+// configureHTTP2 applies the HTTP/2 configuration to the server of l.
+func (ep *Entrypoint) configureHTTP2(l *listener) error {
+	conf := ep.configuration.HTTP2
+	if conf == nil {
+		return nil
+	}
+
+	if !l.tls && !conf.H2C {
+		return nil
+	}
+
+	h2s := &http2.Server{
+		MaxConcurrentStreams: conf.MaxConcurrentStreams,
+		MaxReadFrameSize:     conf.MaxReadFrameSize,
+		IdleTimeout:          conf.IdleTimeout,
+	}
+
+	if l.tls && len(l.server.TLSConfig.NextProtos) == 0 {
+		l.server.TLSConfig.NextProtos = []string{http2.NextProtoTLS, "http/1.1"}
+	}
+
+	// It also registers the HTTP/2 connections for the graceful shutdown of the server.
+	if err := http2.ConfigureServer(l.server, h2s); err != nil {
+		return err
+	}
+
+	if !l.tls {
+		l.server.Handler = &h2cHandler{
+			Handler: l.server.Handler,
+			h2s:     h2s,
+			base:    l.server,
+		}
+	}
+
+	return nil
+}
+
+// h2cHandler serves cleartext HTTP/2 connections, and the other requests by Handler.
+type h2cHandler struct {
+	http.Handler
+	h2s  *http2.Server
+	base *http.Server
+}
+
+func (h *h2cHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "PRI" && r.URL.Path == "*" && r.ProtoMajor == 2 {
+		h.servePriorKnowledge(w)
+		return
+	}
+
+	if isH2CUpgrade(r) {
+		if err := h.serveUpgrade(w, r); err == nil {
+			return
+		} else if err != errBadSettings {
+			Logf(LevelWarn, "h2c upgrade: %v", err)
+			return
+		}
+	}
+
+	h.Handler.ServeHTTP(w, r)
+}
+
+// servePriorKnowledge takes over the connection after the "PRI * HTTP/2.0" line of the preface.
+func (h *h2cHandler) servePriorKnowledge(w http.ResponseWriter) {
+	conn, rw, err := hijack(w)
+	if err != nil {
+		Logf(LevelWarn, "h2c prior knowledge: %v", err)
+		return
+	}
+
+	tail := make([]byte, len(h2cPrefaceTail))
+	if _, err = io.ReadFull(rw, tail); err != nil || string(tail) != h2cPrefaceTail {
+		conn.Close()
+		return
+	}
+
+	h.serveConn(conn, rw.Reader, []byte(h2cPreface), false)
+}
+
+// serveUpgrade switches the connection to HTTP/2 and replays the request as stream 1.
+func (h *h2cHandler) serveUpgrade(w http.ResponseWriter, r *http.Request) error {
+	settings, err := decodeSettings(r.Header.Get("HTTP2-Settings"))
+	if err != nil {
+		return errBadSettings
+	}
+
+	var body []byte
+	if r.Body != nil {
+		if body, err = ioutil.ReadAll(io.LimitReader(r.Body, maxUpgradeBodyBytes)); err != nil {
+			return err
+		}
+	}
+
+	conn, rw, err := hijack(w)
+	if err != nil {
+		return err
+	}
+
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: h2c\r\n\r\n")
+	if err = rw.Flush(); err != nil {
+		conn.Close()
+		return err
+	}
+
+	// The client starts HTTP/2 with its own preface.
+	preface := make([]byte, len(h2cPreface))
+	if _, err = io.ReadFull(rw, preface); err != nil || string(preface) != h2cPreface {
+		conn.Close()
+		return errors.New("client did not send the HTTP/2 preface")
+	}
+
+	prelude, err := upgradePrelude(r, settings, body)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	h.serveConn(conn, rw.Reader, prelude, true)
+	return nil
+}
+
+// serveConn serves HTTP/2 on conn, reading prelude before the buffered client data.
+func (h *h2cHandler) serveConn(conn net.Conn, buffered *bufio.Reader, prelude []byte, swallowAck bool) {
+	// Clear the deadlines set by the HTTP/1.1 server, HTTP/2 manages its own.
+	conn.SetDeadline(time.Time{})
+
+	c := &h2cConn{
+		Conn:   conn,
+		reader: io.MultiReader(bytes.NewReader(prelude), buffered, conn),
+	}
+	if swallowAck {
+		c.writer = &settingsAckSwallower{w: conn}
+	}
+
+	h.h2s.ServeConn(c, &http2.ServeConnOpts{
+		Handler:    h.Handler,
+		BaseConfig: h.base,
+	})
+}
+
+func hijack(w http.ResponseWriter) (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+
+	return hj.Hijack()
+}
+
+func isH2CUpgrade(r *http.Request) bool {
+	if r.ProtoMajor != 1 || !headerContains(r.Header, "Upgrade", "h2c") {
+		return false
+	}
+
+	if !headerContains(r.Header, "Connection", "Upgrade") || !headerContains(r.Header, "Connection", "HTTP2-Settings") {
+		return false
+	}
+
+	return len(r.Header["Http2-Settings"]) == 1 && r.ContentLength >= 0 && r.ContentLength <= maxUpgradeBodyBytes
+}
+
+// headerContains reports whether the comma separated header key holds token.
+func headerContains(h http.Header, key, token string) bool {
+	for _, v := range h[http.CanonicalHeaderKey(key)] {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func decodeSettings(s string) ([]http2.Setting, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil || len(payload)%6 != 0 {
+		return nil, errBadSettings
+	}
+
+	settings := make([]http2.Setting, 0, len(payload)/6)
+	for i := 0; i < len(payload); i += 6 {
+		setting := http2.Setting{
+			ID:  http2.SettingID(binary.BigEndian.Uint16(payload[i:])),
+			Val: binary.BigEndian.Uint32(payload[i+2:]),
+		}
+		if setting.Valid() != nil {
+			return nil, errBadSettings
+		}
+		settings = append(settings, setting)
+	}
+	return settings, nil
+}
+
+// hopHeaders are the HTTP/1.1 connection headers which are not allowed in HTTP/2.
+var hopHeaders = map[string]bool{
+	"connection":        true,
+	"http2-settings":    true,
+	"keep-alive":        true,
+	"proxy-connection":  true,
+	"transfer-encoding": true,
+	"upgrade":           true,
+	"host":              true,
+}
+
+// upgradePrelude returns the frames a client would have sent to make the upgraded request
+// on stream 1: the preface, the settings of the HTTP2-Settings header and the request.
+func upgradePrelude(r *http.Request, settings []http2.Setting, body []byte) ([]byte, error) {
+	var (
+		buf   bytes.Buffer
+		block bytes.Buffer
+	)
+
+	buf.WriteString(h2cPreface)
+	framer := http2.NewFramer(&buf, nil)
+
+	if err := framer.WriteSettings(settings...); err != nil {
+		return nil, err
+	}
+
+	enc := hpack.NewEncoder(&block)
+	enc.WriteField(hpack.HeaderField{Name: ":method", Value: r.Method})
+	enc.WriteField(hpack.HeaderField{Name: ":scheme", Value: "http"})
+	enc.WriteField(hpack.HeaderField{Name: ":authority", Value: r.Host})
+	enc.WriteField(hpack.HeaderField{Name: ":path", Value: r.URL.RequestURI()})
+
+	for key, values := range r.Header {
+		name := strings.ToLower(key)
+		if hopHeaders[name] || (name == "te" && !headerContains(r.Header, "TE", "trailers")) {
+			continue
+		}
+		if name == "te" {
+			values = []string{"trailers"}
+		}
+		for _, v := range values {
+			enc.WriteField(hpack.HeaderField{Name: name, Value: v})
+		}
+	}
+
+	const maxFrameSize = 16384
+	fragment := block.Bytes()
+	first := fragment
+	if len(first) > maxFrameSize {
+		first = first[:maxFrameSize]
+	}
+	fragment = fragment[len(first):]
+
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      1,
+		BlockFragment: first,
+		EndStream:     len(body) == 0,
+		EndHeaders:    len(fragment) == 0,
+	}); err != nil {
+		return nil, err
+	}
+
+	for len(fragment) > 0 {
+		chunk := fragment
+		if len(chunk) > maxFrameSize {
+			chunk = chunk[:maxFrameSize]
+		}
+		fragment = fragment[len(chunk):]
+
+		if err := framer.WriteContinuation(1, len(fragment) == 0, chunk); err != nil {
+			return nil, err
+		}
+	}
+
+	for len(body) > 0 {
+		chunk := body
+		if len(chunk) > maxFrameSize {
+			chunk = chunk[:maxFrameSize]
+		}
+		body = body[len(chunk):]
+
+		if err := framer.WriteData(1, len(body) == 0, chunk); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// h2cConn is a hijacked connection which replays the buffered client data first.
+type h2cConn struct {
+	net.Conn
+	reader io.Reader
+	writer io.Writer
+}
+
+func (c *h2cConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+func (c *h2cConn) Write(b []byte) (int, error) {
+	if c.writer != nil {
+		return c.writer.Write(b)
+	}
+	return c.Conn.Write(b)
+}
+
+// settingsAckSwallower drops the first SETTINGS acknowledgement written by the server, it
+// acknowledges the HTTP2-Settings header which the 101 response implicitly did already.
+type settingsAckSwallower struct {
+	w    io.Writer
+	buf  []byte
+	done bool
+}
+
+func (s *settingsAckSwallower) Write(b []byte) (int, error) {
+	if s.done {
+		return s.w.Write(b)
+	}
+
+	s.buf = append(s.buf, b...)
+	for len(s.buf) >= 9 {
+		length := int(s.buf[0])<<16 | int(s.buf[1])<<8 | int(s.buf[2])
+		if len(s.buf) < 9+length {
+			break
+		}
+
+		frameType, flags := http2.FrameType(s.buf[3]), http2.Flags(s.buf[4])
+		frame := s.buf[:9+length]
+		s.buf = s.buf[9+length:]
+
+		if frameType == http2.FrameSettings && flags.Has(http2.FlagSettingsAck) {
+			s.done = true
+			rest := s.buf
+			s.buf = nil
+			if len(rest) > 0 {
+				if _, err := s.w.Write(rest); err != nil {
+					return 0, err
+				}
+			}
+			return len(b), nil
+		}
+
+		if _, err := s.w.Write(frame); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(b), nil
+}
@@ -0,0 +1,140 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package server
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+func startH2C(t *testing.T) (*Entrypoint, string) {
+	ep := NewEntrypoint(&Configuration{
+		Address: "127.0.0.1:0",
+		HTTP2: &HTTP2Configuration{
+			H2C:                  true,
+			MaxConcurrentStreams: 10,
+		},
+	}, nil)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s %s", r.Proto, r.URL.Path, body)
+	})
+	if err := ep.Start(handler); err != nil {
+		t.Fatal(err)
+	}
+
+	return ep, ep.listeners[0].listener.Addr().String()
+}
+
+func TestH2CPriorKnowledge(t *testing.T) {
+	ep, addr := startH2C(t)
+	defer ep.Stop()
+
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}}
+
+	resp, err := client.Post("http://"+addr+"/prior", "text/plain", strings.NewReader("body"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if string(body) != "HTTP/2.0 /prior body" {
+		t.Fatalf("unexpected body %q", body)
+	}
+}
+
+func TestH2CUpgrade(t *testing.T) {
+	ep, addr := startH2C(t)
+	defer ep.Stop()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// An empty settings payload is a valid HTTP2-Settings header.
+	settings := base64.RawURLEncoding.EncodeToString(nil)
+	fmt.Fprintf(conn, "POST /upgrade HTTP/1.1\r\nHost: %s\r\nConnection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\nHTTP2-Settings: %s\r\nContent-Length: 4\r\n\r\nbody", addr, settings)
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("unexpected status %d", resp.StatusCode)
+	}
+
+	if _, err = conn.Write([]byte(http2.ClientPreface)); err != nil {
+		t.Fatal(err)
+	}
+
+	framer := http2.NewFramer(conn, br)
+	if err = framer.WriteSettings(); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		status string
+		body   []byte
+		acks   int
+	)
+	decoder := hpack.NewDecoder(4096, func(f hpack.HeaderField) {
+		if f.Name == ":status" {
+			status = f.Value
+		}
+	})
+
+	for done := false; !done; {
+		frame, err := framer.ReadFrame()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		switch f := frame.(type) {
+		case *http2.SettingsFrame:
+			if f.IsAck() {
+				acks++
+			} else {
+				framer.WriteSettingsAck()
+			}
+		case *http2.HeadersFrame:
+			if f.StreamID != 1 {
+				t.Fatalf("unexpected stream %d", f.StreamID)
+			}
+			decoder.Write(f.HeaderBlockFragment())
+			done = f.StreamEnded()
+		case *http2.DataFrame:
+			body = append(body, f.Data()...)
+			done = f.StreamEnded()
+		}
+	}
+
+	if status != "200" || string(body) != "HTTP/2.0 /upgrade body" {
+		t.Fatalf("unexpected response %s %q", status, body)
+	}
+	if acks > 1 {
+		t.Fatalf("the HTTP2-Settings header was acknowledged")
+	}
+}
@@ -0,0 +1,115 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// State is the lifecycle state of an Entrypoint.
+type State int32
+
+const (
+	// StateIdle is the state before Start is called.
+	StateIdle State = iota
+	// StateStarting is the state while the start hooks run.
+	StateStarting
+	// StateRunning is the state while requests are served.
+	StateRunning
+	// StateDraining is the state after Stop is called, before the listener closes.
+	StateDraining
+	// StateStopped is the state after the server and the shutdown hooks are done.
+	StateStopped
+)
+
+var stateNames = [...]string{"idle", "starting", "running", "draining", "stopped"}
+
+func (s State) String() string {
+	if int(s) < len(stateNames) {
+		return stateNames[s]
+	}
+	return fmt.Sprintf("State(%d)", int32(s))
+}
+
+// HookFunc is a lifecycle hook, ctx is cancelled when the hook times out.
+type HookFunc func(ctx context.Context) error
+
+type hook struct {
+	name     string
+	priority int
+	timeout  time.Duration
+	fn       HookFunc
+}
+
+// OnStart registers a hook invoked by Start before the server accepts connections.
+// Hooks run by ascending priority, hooks of the same priority in registration order.
+// A zero timeout means the hook is not limited. Start fails on the first hook error.
+func (ep *Entrypoint) OnStart(name string, priority int, timeout time.Duration, fn HookFunc) {
+	ep.startHooks = addHook(ep.startHooks, hook{name, priority, timeout, fn})
+}
+
+// OnShutdown registers a hook invoked by Stop after the server has drained, such as
+// stopping NSQ consumers or cron jobs. Hooks run by ascending priority, hooks of the
+// same priority in registration order. Errors are logged and do not stop the others.
+func (ep *Entrypoint) OnShutdown(name string, priority int, timeout time.Duration, fn HookFunc) {
+	ep.shutdownHooks = addHook(ep.shutdownHooks, hook{name, priority, timeout, fn})
+}
+
+func addHook(hooks []hook, h hook) []hook {
+	hooks = append(hooks, h)
+	sort.SliceStable(hooks, func(i, j int) bool {
+		return hooks[i].priority < hooks[j].priority
+	})
+	return hooks
+}
+
+func (h *hook) run() (err error) {
+	ctx := context.Background()
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("hook %s panicked: %v", h.name, r)
+			}
+		}()
+		done <- h.fn(ctx)
+	}()
+
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	if err != nil {
+		err = fmt.Errorf("hook %s: %v", h.name, err)
+	}
+	return err
+}
+
+// State returns the current lifecycle state.
+func (ep *Entrypoint) State() State {
+	return State(atomic.LoadInt32(&ep.state))
+}
+
+// Ready reports whether the entrypoint is serving and not draining, for readiness probes.
+func (ep *Entrypoint) Ready() bool {
+	return ep.State() == StateRunning
+}
+
+func (ep *Entrypoint) setState(s State) {
+	atomic.StoreInt32(&ep.state, int32(s))
+}
@@ -0,0 +1,191 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/netutil"
+)
+
+var errNetwork = errors.New("listener network must be tcp, tcp4, tcp6 or unix")
+
+// listener is a listening socket and the http server serving it.
+type listener struct {
+	conf         ListenerConfiguration
+	key          string
+	raw          net.Listener
+	listener     net.Listener
+	server       *http.Server
+	tls          bool
+	admin        bool
+	certificates *certificateStore
+}
+
+// listenerConfigurations returns the configured listeners, or the one described by Address.
+func (ep *Entrypoint) listenerConfigurations() []ListenerConfiguration {
+	if len(ep.configuration.Listeners) > 0 {
+		return ep.configuration.Listeners
+	}
+
+	return []ListenerConfiguration{{
+		Network: "tcp",
+		Address: ep.configuration.Address,
+		TLS:     ep.tlsConfig,
+	}}
+}
+
+// newListener creates the socket and the server of conf, handler serves the requests
+// unless the listener redirects to https.
+func (ep *Entrypoint) newListener(conf ListenerConfiguration, handler http.Handler) (*listener, error) {
+	if conf.Network == "" {
+		conf.Network = "tcp"
+	}
+
+	switch conf.Network {
+	case "tcp", "tcp4", "tcp6", "unix":
+	default:
+		return nil, errNetwork
+	}
+
+	l := &listener{
+		conf: conf,
+		key:  listenerKey(conf.Network, conf.Address),
+	}
+
+	tlsConfig, store, err := createTLSConfig(conf.TLS)
+	if err != nil {
+		return nil, err
+	}
+	l.certificates = store
+	l.tls = tlsConfig != nil
+
+	if conf.RedirectPort > 0 {
+		handler = redirectHandler(conf.RedirectPort)
+	}
+
+	if l.raw, err = ep.listen(conf); err != nil {
+		return nil, err
+	}
+
+	l.listener = l.raw
+	if proxy := ep.proxyProtocol(conf); proxy != nil {
+		if l.listener, err = newProxyListener(l.listener, proxy); err != nil {
+			l.raw.Close()
+			return nil, err
+		}
+	}
+
+	if ep.configuration.MaxConnections > 0 {
+		l.listener = netutil.LimitListener(l.listener, ep.configuration.MaxConnections)
+	}
+
+	l.server = &http.Server{
+		Addr:              conf.Address,
+		Handler:           handler,
+		TLSConfig:         tlsConfig,
+		ReadTimeout:       ep.configuration.ReadTimeout,
+		ReadHeaderTimeout: ep.configuration.ReadHeaderTimeout,
+		WriteTimeout:      ep.configuration.WriteTimeout,
+		IdleTimeout:       ep.configuration.IdleTimeout,
+		MaxHeaderBytes:    ep.configuration.MaxHeaderBytes,
+	}
+	l.server.SetKeepAlivesEnabled(!ep.configuration.DisableKeepAlives)
+
+	if err = ep.configureHTTP2(l); err != nil {
+		l.raw.Close()
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// proxyProtocol returns the PROXY protocol configuration of the listener, or the one of the entrypoint.
+func (ep *Entrypoint) proxyProtocol(conf ListenerConfiguration) *ProxyProtocolConfiguration {
+	if conf.ProxyProtocol != nil {
+		return conf.ProxyProtocol
+	}
+	return ep.configuration.ProxyProtocol
+}
+
+// listen announces on the address, or reuses the listener inherited from the parent process.
+// A stale unix socket file is removed first.
+func (ep *Entrypoint) listen(conf ListenerConfiguration) (net.Listener, error) {
+	if l, ok := inherited(conf.Network, conf.Address); ok {
+		return l, nil
+	}
+
+	if conf.Network == "unix" {
+		if info, err := os.Stat(conf.Address); err == nil && info.Mode()&os.ModeSocket != 0 {
+			os.Remove(conf.Address)
+		}
+	}
+
+	l, err := net.Listen(conf.Network, conf.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.Network == "unix" && conf.FileMode != 0 {
+		if err = os.Chmod(conf.Address, conf.FileMode); err != nil {
+			l.Close()
+			return nil, err
+		}
+	}
+
+	return l, nil
+}
+
+func (l *listener) serve() error {
+	if l.tls {
+		return l.server.ServeTLS(l.listener, "", "")
+	}
+
+	return l.server.Serve(l.listener)
+}
+
+func (l *listener) String() string {
+	scheme := "http"
+	if l.tls {
+		scheme = "https"
+	}
+
+	if l.admin {
+		return fmt.Sprintf("%s://%s (admin)", l.conf.Network, l.conf.Address)
+	}
+
+	if l.conf.RedirectPort > 0 {
+		return fmt.Sprintf("%s://%s (redirect to https port %d)", l.conf.Network, l.conf.Address, l.conf.RedirectPort)
+	}
+	return fmt.Sprintf("%s://%s (%s)", l.conf.Network, l.conf.Address, scheme)
+}
+
+// redirectHandler redirects every request to the same URL over https on port.
+func redirectHandler(port int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := (&url.URL{Host: r.Host}).Hostname()
+		if port != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(port))
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+
+		target := "https://" + host + r.URL.RequestURI()
+
+		status := http.StatusMovedPermanently
+		if r.Method != GET && r.Method != HEAD {
+			status = http.StatusPermanentRedirect
+		}
+		http.Redirect(w, r, target, status)
+	})
+}
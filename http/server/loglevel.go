@@ -0,0 +1,91 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package server
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// Level is the severity of a log message.
+type Level int32
+
+const (
+	// LevelDebug is for verbose messages, disabled by default.
+	LevelDebug Level = iota
+	// LevelInfo is the default level.
+	LevelInfo
+	// LevelWarn is for unexpected but handled situations.
+	LevelWarn
+	// LevelError is for failures.
+	LevelError
+)
+
+var (
+	levelNames = [...]string{"debug", "info", "warn", "error"}
+
+	logLevel = int32(LevelInfo)
+)
+
+func (l Level) String() string {
+	if l >= 0 && int(l) < len(levelNames) {
+		return levelNames[l]
+	}
+	return fmt.Sprintf("Level(%d)", int32(l))
+}
+
+// ParseLevel parses a level name such as "debug", case insensitively.
+func ParseLevel(s string) (Level, error) {
+	for i, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return Level(i), nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q", s)
+}
+
+// SetLogLevel sets the minimum level logged by Logf, it can be changed at runtime by the
+// admin listener.
+func SetLogLevel(l Level) {
+	atomic.StoreInt32(&logLevel, int32(l))
+}
+
+// LogLevel returns the minimum level logged by Logf.
+func LogLevel() Level {
+	return Level(atomic.LoadInt32(&logLevel))
+}
+
+// LogEnabled reports whether messages of level l are logged.
+func LogEnabled(l Level) bool {
+	return l >= LogLevel()
+}
+
+// Logf logs a message with the standard logger if level l is enabled.
+func Logf(l Level, format string, v ...interface{}) {
+	if !LogEnabled(l) {
+		return
+	}
+	log.Printf("["+l.String()+"] "+format, v...)
+}
+
+// LevelLogger logs through Logf at a level. It has the Println and Printf methods
+// expected by libraries such as negroni.Logger.
+type LevelLogger Level
+
+// Printf logs a message formatted like fmt.Printf.
+func (l LevelLogger) Printf(format string, v ...interface{}) {
+	Logf(Level(l), format, v...)
+}
+
+// Println logs a message formatted like fmt.Println.
+func (l LevelLogger) Println(v ...interface{}) {
+	if !LogEnabled(Level(l)) {
+		return
+	}
+	Logf(Level(l), "%s", strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
+}
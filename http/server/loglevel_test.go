@@ -0,0 +1,40 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package server
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLogLevel(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	defer SetLogLevel(LogLevel())
+
+	SetLogLevel(LevelInfo)
+	Logf(LevelDebug, "debug %d", 1)
+	LevelLogger(LevelDebug).Println("debug", 2)
+	if buf.Len() != 0 {
+		t.Fatalf("a debug line was logged at info level: %q", buf.String())
+	}
+
+	LevelLogger(LevelInfo).Println("served", 3)
+	if !strings.Contains(buf.String(), "[info] served 3\n") {
+		t.Fatalf("unexpected log %q", buf.String())
+	}
+
+	buf.Reset()
+	SetLogLevel(LevelDebug)
+	Logf(LevelDebug, "debug %d", 1)
+	if !strings.Contains(buf.String(), "[debug] debug 1") {
+		t.Fatalf("a debug line was not logged at debug level: %q", buf.String())
+	}
+}
@@ -0,0 +1,525 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package middleware
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TechCatsLab/apix/http/server"
+	"golang.org/x/sync/singleflight"
+)
+
+// Cache headers. A handler lists the tags of a response in HeaderCacheTag, separated by
+// commas, to invalidate it with ResponseCache.Invalidate; the header is not sent. HeaderXCache
+// tells whether a response was served from the cache.
+const (
+	HeaderCacheTag     = "Cache-Tag"
+	HeaderXCache       = "X-Cache"
+	HeaderETag         = "ETag"
+	HeaderIfNoneMatch  = "If-None-Match"
+	HeaderCacheControl = "Cache-Control"
+	HeaderAge          = "Age"
+)
+
+// cacheableStatus are the statuses stored, as they are cacheable by default in RFC 7231.
+var cacheableStatus = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusNotFound:             true,
+	http.StatusGone:                 true,
+}
+
+// CacheRule caches the GET and HEAD requests of the paths under a prefix.
+type CacheRule struct {
+	// Path is the path prefix of the cached requests, such as "/products/".
+	Path string
+	// TTL is the time a response is served from the cache.
+	TTL time.Duration
+	// Vary are the request headers which select a different response, such as
+	// Accept-Language, they are part of the key.
+	Vary []string
+	// Private caches the requests carrying Authorization or Cookie, with a hash of the
+	// credentials in the key so that each client gets its own responses. These requests
+	// bypass the cache otherwise.
+	Private bool
+}
+
+// CacheOptions configures the response cache.
+type CacheOptions struct {
+	// Rules are the cached routes, the first rule matching a path applies.
+	Rules []CacheRule
+	// MaxBytes is the size of the cache, the least recently used responses are evicted
+	// beyond it, 64 MB if it is zero.
+	MaxBytes int64
+	// MaxEntryBytes is the largest response stored, 1 MB if it is zero.
+	MaxEntryBytes int64
+	// IgnoreQuery are the query parameters left out of the key, such as "utm_source".
+	IgnoreQuery []string
+}
+
+// DefaultCacheOptions returns the options of a 64 MB cache without rules.
+func DefaultCacheOptions() CacheOptions {
+	return CacheOptions{
+		MaxBytes:      64 << 20,
+		MaxEntryBytes: 1 << 20,
+	}
+}
+
+// ResponseCache is a middleware serving the responses of the routes of its rules from an
+// LRU in memory. The concurrent misses of a key wait for a single call of the handler,
+// the stored responses have a strong ETag, and a matching If-None-Match is answered 304.
+// Responses with a Cache-Control of no-store or private, or with cookies, are not stored,
+// and requests with credentials bypass the cache unless their rule is Private.
+type ResponseCache struct {
+	opt    CacheOptions
+	ignore map[string]bool
+	group  singleflight.Group
+
+	mu      sync.Mutex
+	size    int64
+	lru     *list.List
+	entries map[string]*list.Element
+	tags    map[string]map[string]bool
+}
+
+type cacheEntry struct {
+	key     string
+	status  int
+	header  http.Header
+	body    []byte
+	etag    string
+	tags    []string
+	stored  time.Time
+	expires time.Time
+	size    int64
+}
+
+// NewResponseCache creates a ResponseCache, attach it with Entrypoint.AttachMiddleware.
+func NewResponseCache(opt CacheOptions) *ResponseCache {
+	def := DefaultCacheOptions()
+	if opt.MaxBytes <= 0 {
+		opt.MaxBytes = def.MaxBytes
+	}
+	if opt.MaxEntryBytes <= 0 {
+		opt.MaxEntryBytes = def.MaxEntryBytes
+	}
+
+	rc := &ResponseCache{
+		opt:     opt,
+		ignore:  make(map[string]bool),
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+		tags:    make(map[string]map[string]bool),
+	}
+	for _, name := range opt.IgnoreQuery {
+		rc.ignore[name] = true
+	}
+	return rc
+}
+
+// Invalidate removes the responses with any of the tags.
+func (rc *ResponseCache) Invalidate(tags ...string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range rc.tags[tag] {
+			if elem, ok := rc.entries[key]; ok {
+				rc.remove(elem)
+			}
+		}
+	}
+}
+
+// Purge removes every response.
+func (rc *ResponseCache) Purge() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.lru.Init()
+	rc.entries = make(map[string]*list.Element)
+	rc.tags = make(map[string]map[string]bool)
+	rc.size = 0
+}
+
+// Len returns the number of responses stored.
+func (rc *ResponseCache) Len() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.lru.Len()
+}
+
+func (rc *ResponseCache) rule(r *http.Request) *CacheRule {
+	if r.Method != server.GET && r.Method != server.HEAD {
+		return nil
+	}
+
+	for i := range rc.opt.Rules {
+		if pathUnder(r.URL.Path, rc.opt.Rules[i].Path) {
+			return &rc.opt.Rules[i]
+		}
+	}
+	return nil
+}
+
+// pathUnder reports whether path is prefix or under it, segment by segment: "/user"
+// matches "/user" and "/user/1" but not "/users".
+func pathUnder(path, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" || path == prefix {
+		return true
+	}
+	return strings.HasPrefix(path, prefix+"/")
+}
+
+// hasCredentials reports whether r carries credentials selecting a response of its client.
+func hasCredentials(r *http.Request) bool {
+	return r.Header.Get(server.HeaderAuthorization) != "" || r.Header.Get(server.HeaderCookie) != ""
+}
+
+// key is the host, the path, the query sorted without the ignored parameters, the Vary
+// headers, and the hash of the credentials for a Private rule. HEAD requests share the
+// responses of GET.
+func (rc *ResponseCache) key(r *http.Request, rule *CacheRule) string {
+	query := r.URL.Query()
+	for name := range query {
+		if rc.ignore[name] {
+			delete(query, name)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.ToLower(r.Host))
+	b.WriteString(r.URL.Path)
+	b.WriteString("?")
+	b.WriteString(query.Encode())
+
+	for _, name := range rule.Vary {
+		b.WriteString("\n")
+		b.WriteString(strings.ToLower(name))
+		b.WriteString(":")
+		b.WriteString(url.QueryEscape(strings.Join(r.Header[http.CanonicalHeaderKey(name)], ",")))
+	}
+
+	if rule.Private && hasCredentials(r) {
+		sum := sha256.Sum256([]byte(r.Header.Get(server.HeaderAuthorization) + "\n" + strings.Join(r.Header[server.HeaderCookie], "; ")))
+		b.WriteString("\ncredentials:")
+		b.WriteString(hex.EncodeToString(sum[:]))
+	}
+	return b.String()
+}
+
+// ServeHTTP implements negroni.Handler.
+func (rc *ResponseCache) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	rule := rc.rule(r)
+	if rule == nil || rule.TTL <= 0 || (!rule.Private && hasCredentials(r)) {
+		next(w, r)
+		return
+	}
+
+	key := rc.key(r, rule)
+	if entry := rc.get(key); entry != nil {
+		rc.serve(w, r, entry, "HIT")
+		return
+	}
+
+	if r.Method == server.HEAD {
+		next(w, r)
+		return
+	}
+
+	var (
+		leader   bool
+		panicked interface{}
+	)
+
+	v, _, _ := rc.group.Do(key, func() (interface{}, error) {
+		leader = true
+
+		// A panic would block the waiting requests forever.
+		defer func() {
+			panicked = recover()
+		}()
+
+		return rc.fill(w, r, next, key, rule), nil
+	})
+
+	if panicked != nil {
+		panic(panicked)
+	}
+	if leader {
+		return
+	}
+
+	if entry, _ := v.(*cacheEntry); entry != nil {
+		rc.serve(w, r, entry, "HIT")
+		return
+	}
+	next(w, r)
+}
+
+// fill calls the handler, answers the request and stores the response if it can. It
+// returns the stored entry, or nil.
+func (rc *ResponseCache) fill(w http.ResponseWriter, r *http.Request, next http.HandlerFunc, key string, rule *CacheRule) *cacheEntry {
+	cw := &cacheWriter{w: w, header: make(http.Header), max: rc.opt.MaxEntryBytes}
+	next(cw, r)
+
+	if cw.streaming {
+		return nil
+	}
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+
+	entry := rc.entry(key, rule, cw, rule.Private && hasCredentials(r))
+	if entry == nil {
+		cw.commit()
+		return nil
+	}
+
+	rc.put(entry)
+	rc.serve(w, r, entry, "MISS")
+	return entry
+}
+
+// entry returns the entry of a response, or nil if it must not be stored. A private
+// response is stored only under the key of the credentials of its client.
+func (rc *ResponseCache) entry(key string, rule *CacheRule, cw *cacheWriter, private bool) *cacheEntry {
+	if !cacheableStatus[cw.status] || cw.header.Get(server.HeaderSetCookie) != "" {
+		return nil
+	}
+
+	for _, directive := range strings.Split(cw.header.Get(HeaderCacheControl), ",") {
+		switch strings.ToLower(strings.TrimSpace(directive)) {
+		case "no-store", "no-cache":
+			return nil
+		case "private":
+			if !private {
+				return nil
+			}
+		}
+	}
+
+	header := make(http.Header, len(cw.header))
+	copyHeader(header, cw.header)
+
+	var tags []string
+	for _, tag := range strings.Split(header.Get(HeaderCacheTag), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	header.Del(HeaderCacheTag)
+
+	etag := header.Get(HeaderETag)
+	if etag == "" {
+		sum := sha256.Sum256(cw.body.Bytes())
+		etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+	}
+	header.Del(HeaderETag)
+
+	now := time.Now()
+	entry := &cacheEntry{
+		key:     key,
+		status:  cw.status,
+		header:  header,
+		body:    cw.body.Bytes(),
+		etag:    etag,
+		tags:    tags,
+		stored:  now,
+		expires: now.Add(rule.TTL),
+		size:    int64(len(key) + cw.body.Len()),
+	}
+	for name, values := range header {
+		entry.size += int64(len(name))
+		for _, v := range values {
+			entry.size += int64(len(v))
+		}
+	}
+	return entry
+}
+
+// serve writes entry, or 304 if the request has a matching If-None-Match.
+func (rc *ResponseCache) serve(w http.ResponseWriter, r *http.Request, entry *cacheEntry, state string) {
+	header := w.Header()
+	copyHeader(header, entry.header)
+	header.Set(HeaderETag, entry.etag)
+	header.Set(HeaderXCache, state)
+	if state == "HIT" {
+		header.Set(HeaderAge, strconv.Itoa(int(time.Since(entry.stored).Seconds())))
+	}
+
+	if etagMatch(r.Header.Get(HeaderIfNoneMatch), entry.etag) {
+		header.Del(server.HeaderContentLength)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if entry.status != http.StatusNoContent {
+		header.Set(server.HeaderContentLength, strconv.Itoa(len(entry.body)))
+	}
+	w.WriteHeader(entry.status)
+
+	if r.Method != server.HEAD {
+		w.Write(entry.body)
+	}
+}
+
+// etagMatch compares the tags of If-None-Match with the weak comparison of RFC 7232.
+func etagMatch(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func (rc *ResponseCache) get(key string) *cacheEntry {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	elem, ok := rc.entries[key]
+	if !ok {
+		return nil
+	}
+
+	entry := elem.Value.(*cacheEntry)
+	if !time.Now().Before(entry.expires) {
+		rc.remove(elem)
+		return nil
+	}
+
+	rc.lru.MoveToFront(elem)
+	return entry
+}
+
+func (rc *ResponseCache) put(entry *cacheEntry) {
+	if entry.size > rc.opt.MaxBytes {
+		return
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if elem, ok := rc.entries[entry.key]; ok {
+		rc.remove(elem)
+	}
+
+	rc.entries[entry.key] = rc.lru.PushFront(entry)
+	rc.size += entry.size
+
+	for _, tag := range entry.tags {
+		keys, ok := rc.tags[tag]
+		if !ok {
+			keys = make(map[string]bool)
+			rc.tags[tag] = keys
+		}
+		keys[entry.key] = true
+	}
+
+	for rc.size > rc.opt.MaxBytes {
+		rc.remove(rc.lru.Back())
+	}
+}
+
+// remove deletes an element, rc.mu must be held.
+func (rc *ResponseCache) remove(elem *list.Element) {
+	entry := rc.lru.Remove(elem).(*cacheEntry)
+	delete(rc.entries, entry.key)
+	rc.size -= entry.size
+
+	for _, tag := range entry.tags {
+		delete(rc.tags[tag], entry.key)
+		if len(rc.tags[tag]) == 0 {
+			delete(rc.tags, tag)
+		}
+	}
+}
+
+func copyHeader(dst, src http.Header) {
+	for name, values := range src {
+		dst[name] = append([]string(nil), values...)
+	}
+}
+
+// cacheWriter buffers a response to store it. A response larger than max, or flushed by
+// the handler, is streamed to the client and not stored.
+type cacheWriter struct {
+	w         http.ResponseWriter
+	header    http.Header
+	status    int
+	body      bytes.Buffer
+	max       int64
+	streaming bool
+}
+
+func (cw *cacheWriter) Header() http.Header {
+	return cw.header
+}
+
+func (cw *cacheWriter) WriteHeader(status int) {
+	if cw.status == 0 {
+		cw.status = status
+	}
+}
+
+func (cw *cacheWriter) Write(b []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+
+	if !cw.streaming && int64(cw.body.Len()+len(b)) > cw.max {
+		cw.commit()
+	}
+	if cw.streaming {
+		return cw.w.Write(b)
+	}
+	return cw.body.Write(b)
+}
+
+// Flush streams the response.
+func (cw *cacheWriter) Flush() {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	if !cw.streaming {
+		cw.commit()
+	}
+
+	if f, ok := cw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// commit writes the buffered response and switches to streaming.
+func (cw *cacheWriter) commit() {
+	cw.header.Del(HeaderCacheTag)
+	copyHeader(cw.w.Header(), cw.header)
+	cw.w.WriteHeader(cw.status)
+	cw.w.Write(cw.body.Bytes())
+
+	cw.body.Reset()
+	cw.streaming = true
+}
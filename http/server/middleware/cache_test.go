@@ -0,0 +1,254 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/urfave/negroni"
+)
+
+func cacheRequest(n http.Handler, method, target string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+
+	rec := httptest.NewRecorder()
+	n.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestResponseCache(t *testing.T) {
+	var calls int32
+
+	rc := NewResponseCache(CacheOptions{
+		Rules:       []CacheRule{{Path: "/products", TTL: time.Minute, Vary: []string{"Accept-Language"}}},
+		IgnoreQuery: []string{"utm_source"},
+	})
+
+	n := negroni.New(rc)
+	n.UseHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		w.Header().Set(HeaderCacheTag, "products, product-1")
+		fmt.Fprintf(w, "%s %s %d", r.URL.Path, r.Header.Get("Accept-Language"), n)
+	})
+
+	miss := cacheRequest(n, http.MethodGet, "/products/1?b=2&a=1", nil)
+	if miss.Header().Get(HeaderXCache) != "MISS" || miss.Body.String() != "/products/1  1" {
+		t.Fatalf("unexpected miss %v %q", miss.Header(), miss.Body.String())
+	}
+	if miss.Header().Get(HeaderCacheTag) != "" {
+		t.Fatal("the cache tags were sent")
+	}
+
+	etag := miss.Header().Get(HeaderETag)
+	if !strings.HasPrefix(etag, `"`) {
+		t.Fatalf("unexpected ETag %q", etag)
+	}
+
+	hit := cacheRequest(n, http.MethodGet, "/products/1?a=1&utm_source=mail&b=2", nil)
+	if hit.Header().Get(HeaderXCache) != "HIT" || hit.Body.String() != "/products/1  1" || hit.Header().Get(HeaderETag) != etag {
+		t.Fatalf("unexpected hit %v %q", hit.Header(), hit.Body.String())
+	}
+
+	if rec := cacheRequest(n, http.MethodGet, "/products/1?a=1&b=2", map[string]string{HeaderIfNoneMatch: etag}); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Fatalf("unexpected conditional response %d %q", rec.Code, rec.Body.String())
+	}
+	if rec := cacheRequest(n, http.MethodHead, "/products/1?a=1&b=2", nil); rec.Code != http.StatusOK || rec.Body.Len() != 0 || rec.Header().Get(HeaderXCache) != "HIT" {
+		t.Fatalf("unexpected HEAD response %d %q", rec.Code, rec.Body.String())
+	}
+
+	if rec := cacheRequest(n, http.MethodGet, "/products/1?a=1&b=2", map[string]string{"Accept-Language": "fr"}); rec.Body.String() != "/products/1 fr 2" {
+		t.Fatalf("the Vary header was ignored: %q", rec.Body.String())
+	}
+	if rec := cacheRequest(n, http.MethodGet, "/orders/1", nil); rec.Header().Get(HeaderXCache) != "" {
+		t.Fatal("a path without a rule was cached")
+	}
+
+	rc.Invalidate("product-1")
+	if rc.Len() != 0 {
+		t.Fatalf("%d responses remain after the invalidation", rc.Len())
+	}
+	if rec := cacheRequest(n, http.MethodGet, "/products/1?a=1&b=2", nil); rec.Header().Get(HeaderXCache) != "MISS" {
+		t.Fatal("an invalidated response was served")
+	}
+}
+
+func TestResponseCacheNotStored(t *testing.T) {
+	rc := NewResponseCache(CacheOptions{
+		Rules:         []CacheRule{{Path: "/", TTL: time.Minute}},
+		MaxEntryBytes: 8,
+	})
+
+	n := negroni.New(rc)
+	n.UseHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/private":
+			w.Header().Set(HeaderCacheControl, "private, max-age=60")
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/large":
+			w.Write([]byte(strings.Repeat("a", 16)))
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+
+	for _, path := range []string{"/private", "/error", "/large"} {
+		cacheRequest(n, http.MethodGet, path, nil)
+		if rec := cacheRequest(n, http.MethodGet, path, nil); rec.Header().Get(HeaderXCache) != "" {
+			t.Fatalf("%s was served from the cache", path)
+		}
+	}
+
+	if rec := cacheRequest(n, http.MethodGet, "/large", nil); rec.Body.Len() != 16 {
+		t.Fatalf("a large response was truncated to %d bytes", rec.Body.Len())
+	}
+}
+
+func TestResponseCacheCollapse(t *testing.T) {
+	var calls int32
+	entered := make(chan struct{}, 5)
+	release := make(chan struct{})
+
+	rc := NewResponseCache(CacheOptions{Rules: []CacheRule{{Path: "/", TTL: time.Minute}}})
+	n := negroni.New(rc)
+	n.UseHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		entered <- struct{}{}
+		<-release
+		w.Write([]byte("slow"))
+	})
+
+	var wg sync.WaitGroup
+	bodies := make([]string, 5)
+	request := func(i int) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			bodies[i] = cacheRequest(n, http.MethodGet, "/report", nil).Body.String()
+		}()
+	}
+
+	// The other requests miss while the first one is in the handler, they either wait
+	// for it or find its response once it is stored.
+	request(0)
+	<-entered
+	for i := 1; i < len(bodies); i++ {
+		request(i)
+	}
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatalf("the handler was called %d times", calls)
+	}
+	for _, body := range bodies {
+		if body != "slow" {
+			t.Fatalf("unexpected body %q", body)
+		}
+	}
+}
+
+func TestResponseCacheEviction(t *testing.T) {
+	rc := NewResponseCache(CacheOptions{
+		Rules:    []CacheRule{{Path: "/", TTL: time.Minute}},
+		MaxBytes: 102,
+	})
+
+	n := negroni.New(rc)
+	n.UseHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("a", 30)))
+	})
+
+	cacheRequest(n, http.MethodGet, "/1", nil)
+	cacheRequest(n, http.MethodGet, "/2", nil)
+	cacheRequest(n, http.MethodGet, "/1", nil)
+	cacheRequest(n, http.MethodGet, "/3", nil)
+
+	if rc.Len() != 2 {
+		t.Fatalf("%d responses are stored", rc.Len())
+	}
+	if rec := cacheRequest(n, http.MethodGet, "/1", nil); rec.Header().Get(HeaderXCache) != "HIT" {
+		t.Fatal("the recently used response was evicted")
+	}
+	if rec := cacheRequest(n, http.MethodGet, "/2", nil); rec.Header().Get(HeaderXCache) != "MISS" {
+		t.Fatal("the least recently used response was kept")
+	}
+}
+
+func TestResponseCacheCredentials(t *testing.T) {
+	var calls int32
+
+	rc := NewResponseCache(CacheOptions{Rules: []CacheRule{
+		{Path: "/account", TTL: time.Minute, Private: true},
+		{Path: "/", TTL: time.Minute},
+	}})
+
+	n := negroni.New(rc)
+	n.UseHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set(HeaderCacheControl, "private, max-age=60")
+		w.Write([]byte(r.Header.Get("Authorization")))
+	})
+
+	alice := map[string]string{"Authorization": "Bearer alice"}
+	bob := map[string]string{"Authorization": "Bearer bob"}
+
+	cacheRequest(n, http.MethodGet, "/orders", alice)
+	if rec := cacheRequest(n, http.MethodGet, "/orders", bob); rec.Body.String() != "Bearer bob" || rec.Header().Get(HeaderXCache) != "" {
+		t.Fatalf("a response to credentials was shared: %q %v", rec.Body.String(), rec.Header())
+	}
+	if rc.Len() != 0 {
+		t.Fatalf("%d responses to credentials are stored", rc.Len())
+	}
+
+	cacheRequest(n, http.MethodGet, "/account", alice)
+	if rec := cacheRequest(n, http.MethodGet, "/account", bob); rec.Body.String() != "Bearer bob" || rec.Header().Get(HeaderXCache) != "MISS" {
+		t.Fatalf("a private response was served to another client: %q %v", rec.Body.String(), rec.Header())
+	}
+	if rec := cacheRequest(n, http.MethodGet, "/account", alice); rec.Body.String() != "Bearer alice" || rec.Header().Get(HeaderXCache) != "HIT" {
+		t.Fatalf("unexpected private response %q %v", rec.Body.String(), rec.Header())
+	}
+	if calls != 4 {
+		t.Fatalf("the handler was called %d times", calls)
+	}
+}
+
+func TestResponseCacheHostAndSegments(t *testing.T) {
+	rc := NewResponseCache(CacheOptions{Rules: []CacheRule{{Path: "/user", TTL: time.Minute}}})
+
+	n := negroni.New(rc)
+	n.UseHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host))
+	})
+
+	for _, path := range []string{"/user", "/user/1"} {
+		cacheRequest(n, http.MethodGet, path, nil)
+		if rec := cacheRequest(n, http.MethodGet, path, nil); rec.Header().Get(HeaderXCache) != "HIT" {
+			t.Fatalf("%s was not cached", path)
+		}
+	}
+	if rec := cacheRequest(n, http.MethodGet, "/users/1", nil); rec.Header().Get(HeaderXCache) != "" {
+		t.Fatal("the rule of /user matched /users/1")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/user", nil)
+	req.Host = "other.example.com"
+	rec := httptest.NewRecorder()
+	n.ServeHTTP(rec, req)
+	if rec.Header().Get(HeaderXCache) != "MISS" || rec.Body.String() != "other.example.com" {
+		t.Fatalf("a response of another host was served: %q %v", rec.Body.String(), rec.Header())
+	}
+}
@@ -0,0 +1,363 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/TechCatsLab/apix/http/server"
+	"github.com/TechCatsLab/apix/metrics"
+	"github.com/urfave/negroni"
+)
+
+// LimitAlgorithm selects how a ConcurrencyLimiter adjusts its limit.
+type LimitAlgorithm int
+
+const (
+	// FixedLimit keeps the configured limit.
+	FixedLimit LimitAlgorithm = iota
+	// AIMDLimit increases the limit by one after a fast response while the limit is in
+	// use, and multiplies it by BackoffRatio after a response slower than LatencyThreshold
+	// or a 503 or 504.
+	AIMDLimit
+	// GradientLimit follows the ratio of the long term average latency to the latency of
+	// each response: the limit grows while the latency stays close to the average and the
+	// limit is in use, and shrinks as requests queue up in the service and the latency
+	// grows.
+	GradientLimit
+)
+
+const (
+	// gradientTolerance is the latency growth tolerated before the limit shrinks.
+	gradientTolerance = 1.5
+	// gradientSmoothing weights a new limit against the current one.
+	gradientSmoothing = 0.2
+	// gradientWindow is the number of samples of the long term average latency.
+	gradientWindow = 600
+)
+
+const (
+	rejectQueueFull = "queue_full"
+	rejectTimeout   = "timeout"
+)
+
+// testHookQueued is called as a request joins the queue.
+var testHookQueued = func() {}
+
+// ConcurrencyOptions configures a ConcurrencyLimiter.
+type ConcurrencyOptions struct {
+	// Name labels the metrics of the limiter, "global" if it is empty.
+	Name string
+	// Limit is the initial number of requests in flight, 100 if it is zero.
+	Limit int
+	// MinLimit and MaxLimit bound an adaptive limit, 1 and 1000 if they are zero.
+	MinLimit int
+	MaxLimit int
+	// Algorithm adjusts the limit from the observed latency.
+	Algorithm LimitAlgorithm
+
+	// QueueSize is the number of requests waiting for a slot, the requests over the limit
+	// are rejected at once if it is zero.
+	QueueSize int
+	// QueueTimeout is the longest wait for a slot, 1 second if it is zero.
+	QueueTimeout time.Duration
+	// RetryAfter is sent to the rejected clients, 1 second if it is zero.
+	RetryAfter time.Duration
+
+	// LatencyThreshold is the latency decreasing an AIMD limit, 1 second if it is zero.
+	LatencyThreshold time.Duration
+	// BackoffRatio multiplies an AIMD limit on a slow response, 0.9 if it is zero.
+	BackoffRatio float64
+
+	// Registry receives the metrics, metrics.DefaultRegistry if it is nil.
+	Registry *metrics.Registry
+	// Namespace prefixes the metric names, "http" if it is empty.
+	Namespace string
+}
+
+// DefaultConcurrencyOptions returns the options of a fixed limit of 100 requests without
+// queue.
+func DefaultConcurrencyOptions() ConcurrencyOptions {
+	return ConcurrencyOptions{
+		Name:             "global",
+		Limit:            100,
+		MinLimit:         1,
+		MaxLimit:         1000,
+		QueueTimeout:     time.Second,
+		RetryAfter:       time.Second,
+		LatencyThreshold: time.Second,
+		BackoffRatio:     0.9,
+		Registry:         metrics.DefaultRegistry,
+		Namespace:        "http",
+	}
+}
+
+// ConcurrencyLimiter sheds the requests over a limit of requests in flight, answering
+// 503 Service Unavailable with Retry-After. Attached to an Entrypoint it caps the whole
+// service, and wrapping the handlers of some routes it is a bulkhead isolating them:
+//
+//	ep.AttachMiddleware(middleware.NewConcurrencyLimiter(opt))
+//
+//	search := middleware.NewConcurrencyLimiter(middleware.ConcurrencyOptions{Name: "search", Limit: 20})
+//	rt.Get("/search", search.Handle(searchHandler))
+//
+// The limiter exports the gauges <namespace>_concurrency_limit, _concurrency_in_flight
+// and _concurrency_queued, and the counter _concurrency_rejected_total, labelled by
+// limiter.
+type ConcurrencyLimiter struct {
+	opt ConcurrencyOptions
+
+	mu       sync.Mutex
+	limit    float64
+	inFlight int
+	queue    []*concurrencyWaiter
+	longRTT  float64
+	samples  int
+
+	limitGauge    *metrics.Gauge
+	inFlightGauge *metrics.Gauge
+	queuedGauge   *metrics.Gauge
+	rejected      *metrics.Counter
+}
+
+type concurrencyWaiter struct {
+	ready   chan struct{}
+	granted bool
+}
+
+// NewConcurrencyLimiter creates a ConcurrencyLimiter.
+func NewConcurrencyLimiter(opt ConcurrencyOptions) *ConcurrencyLimiter {
+	def := DefaultConcurrencyOptions()
+	if opt.Name == "" {
+		opt.Name = def.Name
+	}
+	if opt.Limit <= 0 {
+		opt.Limit = def.Limit
+	}
+	if opt.MinLimit <= 0 {
+		opt.MinLimit = def.MinLimit
+	}
+	if opt.MaxLimit <= 0 {
+		opt.MaxLimit = def.MaxLimit
+	}
+	if opt.MaxLimit < opt.Limit {
+		opt.MaxLimit = opt.Limit
+	}
+	if opt.QueueTimeout <= 0 {
+		opt.QueueTimeout = def.QueueTimeout
+	}
+	if opt.RetryAfter <= 0 {
+		opt.RetryAfter = def.RetryAfter
+	}
+	if opt.LatencyThreshold <= 0 {
+		opt.LatencyThreshold = def.LatencyThreshold
+	}
+	if opt.BackoffRatio <= 0 || opt.BackoffRatio >= 1 {
+		opt.BackoffRatio = def.BackoffRatio
+	}
+	if opt.Registry == nil {
+		opt.Registry = def.Registry
+	}
+	if opt.Namespace == "" {
+		opt.Namespace = def.Namespace
+	}
+
+	prefix := opt.Namespace + "_concurrency_"
+	l := &ConcurrencyLimiter{
+		opt:           opt,
+		limit:         float64(opt.Limit),
+		limitGauge:    opt.Registry.NewGauge(prefix+"limit", "Current limit of requests in flight.", "limiter"),
+		inFlightGauge: opt.Registry.NewGauge(prefix+"in_flight", "Number of requests in flight under the limit.", "limiter"),
+		queuedGauge:   opt.Registry.NewGauge(prefix+"queued", "Number of requests waiting for the limit.", "limiter"),
+		rejected:      opt.Registry.NewCounter(prefix+"rejected_total", "Number of requests rejected by the limit.", "limiter", "reason"),
+	}
+	l.limitGauge.Set(l.limit, opt.Name)
+
+	return l
+}
+
+// Limit returns the current limit.
+func (l *ConcurrencyLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+// InFlight returns the number of requests in flight.
+func (l *ConcurrencyLimiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inFlight
+}
+
+// ServeHTTP implements negroni.Handler.
+func (l *ConcurrencyLimiter) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !l.acquire(r.Context()) {
+		l.reject(w)
+		return
+	}
+
+	rw, ok := w.(negroni.ResponseWriter)
+	if !ok {
+		rw = negroni.NewResponseWriter(w)
+	}
+
+	start := time.Now()
+	defer func() {
+		l.release(time.Since(start), rw.Status())
+	}()
+
+	next(rw, r)
+}
+
+// Handle wraps the handler of a route in the limiter.
+func (l *ConcurrencyLimiter) Handle(h server.HandlerFunc) server.HandlerFunc {
+	return func(c *server.Context) error {
+		if !l.acquire(c.Request().Context()) {
+			l.reject(c.Response())
+			return nil
+		}
+
+		var status int
+		start := time.Now()
+		defer func() {
+			if rw, ok := c.Response().(negroni.ResponseWriter); ok {
+				status = rw.Status()
+			}
+			l.release(time.Since(start), status)
+		}()
+
+		return h(c)
+	}
+}
+
+func (l *ConcurrencyLimiter) reject(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(l.opt.RetryAfter.Seconds()))))
+	http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+}
+
+// acquire takes a slot, waiting in the queue if it is full.
+func (l *ConcurrencyLimiter) acquire(ctx context.Context) bool {
+	l.mu.Lock()
+
+	if l.inFlight < int(l.limit) && len(l.queue) == 0 {
+		l.inFlight++
+		l.inFlightGauge.Set(float64(l.inFlight), l.opt.Name)
+		l.mu.Unlock()
+		return true
+	}
+
+	if len(l.queue) >= l.opt.QueueSize {
+		l.mu.Unlock()
+		l.rejected.Inc(l.opt.Name, rejectQueueFull)
+		return false
+	}
+
+	waiter := &concurrencyWaiter{ready: make(chan struct{})}
+	l.queue = append(l.queue, waiter)
+	l.queuedGauge.Set(float64(len(l.queue)), l.opt.Name)
+	l.mu.Unlock()
+	testHookQueued()
+
+	timer := time.NewTimer(l.opt.QueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-waiter.ready:
+		return true
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// The slot may have been granted while the wait ended.
+	if waiter.granted {
+		return true
+	}
+
+	for i, w := range l.queue {
+		if w == waiter {
+			l.queue = append(l.queue[:i], l.queue[i+1:]...)
+			break
+		}
+	}
+	l.queuedGauge.Set(float64(len(l.queue)), l.opt.Name)
+	l.rejected.Inc(l.opt.Name, rejectTimeout)
+	return false
+}
+
+// release frees a slot, adjusts the limit from the latency of the request and hands the
+// free slots to the waiting requests.
+func (l *ConcurrencyLimiter) release(latency time.Duration, status int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	inFlight := l.inFlight
+	l.inFlight--
+
+	switch l.opt.Algorithm {
+	case AIMDLimit:
+		l.aimd(latency, status, inFlight)
+	case GradientLimit:
+		l.gradient(latency, inFlight)
+	}
+	l.limit = math.Max(float64(l.opt.MinLimit), math.Min(float64(l.opt.MaxLimit), l.limit))
+
+	for len(l.queue) > 0 && l.inFlight < int(l.limit) {
+		waiter := l.queue[0]
+		l.queue = l.queue[1:]
+
+		waiter.granted = true
+		close(waiter.ready)
+		l.inFlight++
+	}
+
+	l.limitGauge.Set(math.Floor(l.limit), l.opt.Name)
+	l.inFlightGauge.Set(float64(l.inFlight), l.opt.Name)
+	l.queuedGauge.Set(float64(len(l.queue)), l.opt.Name)
+}
+
+// aimd adjusts the limit from a request which had inFlight requests in flight with it.
+func (l *ConcurrencyLimiter) aimd(latency time.Duration, status, inFlight int) {
+	if latency > l.opt.LatencyThreshold || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout {
+		l.limit *= l.opt.BackoffRatio
+		return
+	}
+
+	// Growing an idle limit would not reflect any capacity.
+	if float64(inFlight)*2 >= l.limit {
+		l.limit++
+	}
+}
+
+// gradient adjusts the limit from a request which had inFlight requests in flight with it.
+func (l *ConcurrencyLimiter) gradient(latency time.Duration, inFlight int) {
+	rtt := latency.Seconds()
+	if rtt <= 0 {
+		return
+	}
+
+	if l.samples < gradientWindow {
+		l.samples++
+	}
+	l.longRTT += (rtt - l.longRTT) / float64(l.samples)
+
+	gradient := math.Max(0.5, math.Min(1, gradientTolerance*l.longRTT/rtt))
+	next := l.limit*gradient + math.Sqrt(l.limit)
+
+	// Growing an idle limit would not reflect any capacity, it may still shrink.
+	if next > l.limit && float64(inFlight)*2 < l.limit {
+		return
+	}
+	l.limit = l.limit*(1-gradientSmoothing) + next*gradientSmoothing
+}
@@ -0,0 +1,239 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TechCatsLab/apix/http/server"
+	"github.com/TechCatsLab/apix/metrics"
+	"github.com/urfave/negroni"
+)
+
+// blockingLimiter returns a handler behind l blocking until release is closed, and a
+// channel receiving a value as each request enters the handler.
+func blockingLimiter(l *ConcurrencyLimiter, release chan struct{}) (http.Handler, chan struct{}) {
+	entered := make(chan struct{}, 16)
+
+	n := negroni.New(l)
+	n.UseHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+		w.Write([]byte("ok"))
+	})
+	return n, entered
+}
+
+func TestConcurrencyLimiterReject(t *testing.T) {
+	reg := metrics.NewRegistry()
+	l := NewConcurrencyLimiter(ConcurrencyOptions{Limit: 2, RetryAfter: 3 * time.Second, Registry: reg})
+
+	release := make(chan struct{})
+	h, entered := blockingLimiter(l, release)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cacheRequest(h, http.MethodGet, "/", nil)
+		}()
+		<-entered
+	}
+
+	rec := cacheRequest(h, http.MethodGet, "/", nil)
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "3" {
+		t.Fatalf("unexpected response %d %v", rec.Code, rec.Header())
+	}
+
+	if v := reg.NewGauge("http_concurrency_in_flight", "", "limiter").Value("global"); v != 2 {
+		t.Fatalf("%v requests in flight", v)
+	}
+	if v := reg.NewCounter("http_concurrency_rejected_total", "", "limiter", "reason").Value("global", rejectQueueFull); v != 1 {
+		t.Fatalf("%v requests rejected", v)
+	}
+
+	close(release)
+	wg.Wait()
+
+	if l.InFlight() != 0 {
+		t.Fatalf("%d requests remain in flight", l.InFlight())
+	}
+	if rec := cacheRequest(h, http.MethodGet, "/", nil); rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d", rec.Code)
+	}
+}
+
+func TestConcurrencyLimiterQueue(t *testing.T) {
+	reg := metrics.NewRegistry()
+	l := NewConcurrencyLimiter(ConcurrencyOptions{Limit: 1, QueueSize: 1, QueueTimeout: time.Second, Registry: reg})
+
+	release := make(chan struct{})
+	h, entered := blockingLimiter(l, release)
+
+	first := make(chan int)
+	go func() { first <- cacheRequest(h, http.MethodGet, "/", nil).Code }()
+	<-entered
+
+	joined := make(chan struct{})
+	testHookQueued = func() { close(joined) }
+	defer func() { testHookQueued = func() {} }()
+
+	queued := make(chan int)
+	go func() { queued <- cacheRequest(h, http.MethodGet, "/", nil).Code }()
+	<-joined
+
+	if v := reg.NewGauge("http_concurrency_queued", "", "limiter").Value("global"); v != 1 {
+		t.Fatalf("%v requests queued", v)
+	}
+
+	if rec := cacheRequest(h, http.MethodGet, "/", nil); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("a request over the full queue got %d", rec.Code)
+	}
+
+	close(release)
+	if code := <-first; code != http.StatusOK {
+		t.Fatalf("unexpected status %d", code)
+	}
+	if code := <-queued; code != http.StatusOK {
+		t.Fatalf("the queued request got %d", code)
+	}
+}
+
+func TestConcurrencyLimiterQueueTimeout(t *testing.T) {
+	reg := metrics.NewRegistry()
+	l := NewConcurrencyLimiter(ConcurrencyOptions{Limit: 1, QueueSize: 4, QueueTimeout: 20 * time.Millisecond, Registry: reg})
+
+	release := make(chan struct{})
+	h, entered := blockingLimiter(l, release)
+	defer close(release)
+
+	go cacheRequest(h, http.MethodGet, "/", nil)
+	<-entered
+
+	if rec := cacheRequest(h, http.MethodGet, "/", nil); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("the queued request got %d", rec.Code)
+	}
+	if v := reg.NewCounter("http_concurrency_rejected_total", "", "limiter", "reason").Value("global", rejectTimeout); v != 1 {
+		t.Fatalf("%v requests timed out", v)
+	}
+	if v := reg.NewGauge("http_concurrency_queued", "", "limiter").Value("global"); v != 0 {
+		t.Fatalf("%v requests remain queued", v)
+	}
+}
+
+func TestConcurrencyLimiterAIMD(t *testing.T) {
+	reg := metrics.NewRegistry()
+	l := NewConcurrencyLimiter(ConcurrencyOptions{
+		Limit:            10,
+		MinLimit:         5,
+		Algorithm:        AIMDLimit,
+		LatencyThreshold: 100 * time.Millisecond,
+		BackoffRatio:     0.5,
+		Registry:         reg,
+	})
+
+	l.acquire(context.Background())
+	l.release(time.Second, http.StatusOK)
+	if l.Limit() != 5 {
+		t.Fatalf("a slow response left the limit at %d", l.Limit())
+	}
+
+	l.acquire(context.Background())
+	l.release(time.Millisecond, http.StatusServiceUnavailable)
+	if l.Limit() != 5 {
+		t.Fatalf("the limit went under its minimum to %d", l.Limit())
+	}
+
+	for i := 0; i < 3; i++ {
+		l.acquire(context.Background())
+	}
+	l.release(time.Millisecond, http.StatusOK)
+	if l.Limit() != 6 {
+		t.Fatalf("a fast response left the limit at %d", l.Limit())
+	}
+	if v := reg.NewGauge("http_concurrency_limit", "", "limiter").Value("global"); v != 6 {
+		t.Fatalf("the limit gauge is %v", v)
+	}
+
+	// The limit does not grow while it is mostly unused.
+	l.release(time.Millisecond, http.StatusOK)
+	l.release(time.Millisecond, http.StatusOK)
+	if l.Limit() != 6 {
+		t.Fatalf("an idle limit grew to %d", l.Limit())
+	}
+}
+
+func TestConcurrencyLimiterGradient(t *testing.T) {
+	l := NewConcurrencyLimiter(ConcurrencyOptions{Limit: 20, Algorithm: GradientLimit, Registry: metrics.NewRegistry()})
+
+	// The limit does not grow while it is mostly unused.
+	for i := 0; i < 50; i++ {
+		l.acquire(context.Background())
+		l.release(10*time.Millisecond, http.StatusOK)
+	}
+	if l.Limit() != 20 {
+		t.Fatalf("an idle limit grew to %d", l.Limit())
+	}
+
+	for i := 0; i < 15; i++ {
+		l.acquire(context.Background())
+	}
+	for i := 0; i < 50; i++ {
+		l.acquire(context.Background())
+		l.release(10*time.Millisecond, http.StatusOK)
+	}
+	grown := l.Limit()
+	if grown <= 20 {
+		t.Fatalf("a steady latency left the limit at %d", grown)
+	}
+
+	for i := 0; i < 50; i++ {
+		l.acquire(context.Background())
+		l.release(100*time.Millisecond, http.StatusOK)
+	}
+	if l.Limit() >= grown {
+		t.Fatalf("a growing latency raised the limit from %d to %d", grown, l.Limit())
+	}
+}
+
+func TestConcurrencyLimiterHandle(t *testing.T) {
+	l := NewConcurrencyLimiter(ConcurrencyOptions{Name: "reports", Limit: 1, Registry: metrics.NewRegistry()})
+
+	release := make(chan struct{})
+	entered := make(chan struct{})
+
+	rt := server.NewRouter()
+	rt.Get("/reports", l.Handle(func(c *server.Context) error {
+		entered <- struct{}{}
+		<-release
+		return c.WriteHeader(http.StatusOK)
+	}))
+	rt.Get("/users", func(c *server.Context) error {
+		return c.WriteHeader(http.StatusOK)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		cacheRequest(rt, http.MethodGet, "/reports", nil)
+		close(done)
+	}()
+	<-entered
+
+	if rec := cacheRequest(rt, http.MethodGet, "/reports", nil); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("the bulkhead let a request through: %d", rec.Code)
+	}
+	if rec := cacheRequest(rt, http.MethodGet, "/users", nil); rec.Code != http.StatusOK {
+		t.Fatalf("another route was limited: %d", rec.Code)
+	}
+
+	close(release)
+	<-done
+}
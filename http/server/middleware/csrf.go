@@ -0,0 +1,465 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TechCatsLab/apix/http/server"
+	"github.com/urfave/negroni"
+)
+
+const (
+	csrfTokenLength = 32
+	csrfSweepPeriod = time.Minute
+)
+
+// CSRFMode selects where the CSRF token is kept.
+type CSRFMode int
+
+const (
+	// CSRFCookie keeps the token in a HttpOnly cookie, the client must echo the masked
+	// token rendered by server.Context.CSRFToken.
+	CSRFCookie CSRFMode = iota
+	// CSRFDoubleSubmit keeps the token in a cookie readable by scripts, the client must
+	// echo the cookie value or the masked token.
+	CSRFDoubleSubmit
+	// CSRFSynchronizer keeps the token on the server, in the Store under the session of
+	// the client, and issues no cookie. The client must echo the masked token rendered
+	// by server.Context.CSRFToken, the unsafe requests without a session are rejected.
+	CSRFSynchronizer
+)
+
+var (
+	errCSRFNoToken       = errors.New("csrf token is missing")
+	errCSRFBadToken      = errors.New("csrf token is invalid")
+	errCSRFBadOrigin     = errors.New("csrf origin is not trusted")
+	errCSRFNoReferer     = errors.New("csrf referer is missing")
+	errCSRFBadReferer    = errors.New("csrf referer is not trusted")
+	errCSRFTokenGenerate = errors.New("csrf token generation failed")
+	errCSRFNoSession     = errors.New("csrf session is missing")
+	errCSRFNoSessionFunc = errors.New("middleware: CSRFSynchronizer mode requires a Session function")
+)
+
+// CSRFStore keeps the tokens of the sessions in CSRFSynchronizer mode, it must be safe for
+// concurrent use and may be shared by the instances of a service.
+type CSRFStore interface {
+	// Token returns the token of session, nil if it has none.
+	Token(session string) ([]byte, error)
+	// SaveToken stores the token of session.
+	SaveToken(session string, token []byte, ttl time.Duration) error
+	// DeleteToken removes the token of session, such as on a login or a logout, so that a
+	// new one is issued.
+	DeleteToken(session string) error
+}
+
+// CSRFOptions configures the CSRF middleware.
+type CSRFOptions struct {
+	Mode CSRFMode
+
+	CookieName   string
+	CookiePath   string
+	CookieDomain string
+	CookieMaxAge int
+	Secure       bool
+	SameSite     http.SameSite
+
+	// HeaderName and FieldName are checked in this order on unsafe methods.
+	HeaderName string
+	FieldName  string
+
+	// TrustedOrigins are the additional hosts, e.g. "app.example.com", accepted in Origin and Referer.
+	TrustedOrigins []string
+
+	// Session returns the session identifier of a request in CSRFSynchronizer mode, such
+	// as the value of the session cookie, or "" if there is no session. It is required in
+	// this mode.
+	Session func(r *http.Request) string
+	// Store keeps the tokens in CSRFSynchronizer mode, a MemoryCSRFStore if it is nil. A
+	// token is only written when a session has none, deleting it rotates the token.
+	Store CSRFStore
+	// TokenTTL is the lifetime of a token in CSRFSynchronizer mode, CookieMaxAge seconds if
+	// it is zero. A new token is issued once it expires.
+	TokenTTL time.Duration
+
+	// Skipper exempts paths from the check, the token is still issued.
+	Skipper Skipper
+
+	// ErrorHandler writes the response of a rejected request, defaults to a plain-text 403.
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// DefaultCSRFOptions returns the default CSRF options.
+func DefaultCSRFOptions() CSRFOptions {
+	return CSRFOptions{
+		Mode:         CSRFCookie,
+		CookieName:   "_csrf",
+		CookiePath:   "/",
+		CookieMaxAge: 12 * 3600,
+		Secure:       true,
+		SameSite:     http.SameSiteLaxMode,
+		HeaderName:   "X-CSRF-Token",
+		FieldName:    "csrf_token",
+	}
+}
+
+type csrf struct {
+	opt CSRFOptions
+}
+
+// NegroniCSRFHandler returns a middleware protecting unsafe methods against cross site request
+// forgery, or an error if the options are invalid.
+func NegroniCSRFHandler(opt CSRFOptions) (negroni.Handler, error) {
+	def := DefaultCSRFOptions()
+	if opt.CookieName == "" {
+		opt.CookieName = def.CookieName
+	}
+	if opt.CookiePath == "" {
+		opt.CookiePath = def.CookiePath
+	}
+	if opt.HeaderName == "" {
+		opt.HeaderName = def.HeaderName
+	}
+	if opt.FieldName == "" {
+		opt.FieldName = def.FieldName
+	}
+	if opt.Skipper == nil {
+		opt.Skipper = defaulSkiper
+	}
+	if opt.ErrorHandler == nil {
+		opt.ErrorHandler = csrfErrorHandler
+	}
+	if opt.Mode == CSRFSynchronizer {
+		if opt.Session == nil {
+			return nil, errCSRFNoSessionFunc
+		}
+		if opt.Store == nil {
+			opt.Store = NewMemoryCSRFStore()
+		}
+		if opt.TokenTTL <= 0 {
+			opt.TokenTTL = time.Duration(opt.CookieMaxAge) * time.Second
+		}
+		if opt.TokenTTL <= 0 {
+			opt.TokenTTL = time.Duration(def.CookieMaxAge) * time.Second
+		}
+	}
+
+	cs := &csrf{opt: opt}
+
+	return negroni.HandlerFunc(cs.handler), nil
+}
+
+func csrfErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	http.Error(w, http.StatusText(http.StatusForbidden)+": "+err.Error(), http.StatusForbidden)
+}
+
+func (cs *csrf) handler(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	w.Header().Add(server.HeaderVary, server.HeaderCookie)
+
+	realToken, err := cs.token(w, r)
+	if err != nil {
+		cs.opt.ErrorHandler(w, r, err)
+		return
+	}
+
+	if realToken != nil {
+		masked, err := maskToken(realToken)
+		if err != nil {
+			cs.opt.ErrorHandler(w, r, errCSRFTokenGenerate)
+			return
+		}
+		r = server.WithCSRFToken(r, masked)
+	}
+
+	if isSafeMethod(r.Method) || cs.opt.Skipper(r.URL.Path) {
+		next(w, r)
+		return
+	}
+
+	if err := cs.checkOrigin(r); err != nil {
+		cs.opt.ErrorHandler(w, r, err)
+		return
+	}
+
+	if realToken == nil {
+		cs.opt.ErrorHandler(w, r, errCSRFNoSession)
+		return
+	}
+
+	sent := cs.requestToken(r)
+	if sent == "" {
+		cs.opt.ErrorHandler(w, r, errCSRFNoToken)
+		return
+	}
+
+	if !cs.validToken(realToken, sent) {
+		cs.opt.ErrorHandler(w, r, errCSRFBadToken)
+		return
+	}
+
+	next(w, r)
+}
+
+// token returns the token of the client, issuing one if it has none. It is nil for a
+// request without a session in CSRFSynchronizer mode.
+func (cs *csrf) token(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	if cs.opt.Mode != CSRFSynchronizer {
+		if token := cs.tokenFromCookie(r); token != nil {
+			return token, nil
+		}
+
+		token, err := randomBytes(csrfTokenLength)
+		if err != nil {
+			return nil, errCSRFTokenGenerate
+		}
+		cs.saveCookie(w, token)
+		return token, nil
+	}
+
+	session := cs.opt.Session(r)
+	if session == "" {
+		return nil, nil
+	}
+
+	token, err := cs.opt.Store.Token(session)
+	if err != nil {
+		server.Logf(server.LevelError, "CSRF store failed: %v", err)
+		return nil, errCSRFTokenGenerate
+	}
+	if token != nil {
+		return token, nil
+	}
+
+	if token, err = randomBytes(csrfTokenLength); err != nil {
+		return nil, errCSRFTokenGenerate
+	}
+	if err = cs.opt.Store.SaveToken(session, token, cs.opt.TokenTTL); err != nil {
+		server.Logf(server.LevelError, "CSRF store failed: %v", err)
+		return nil, errCSRFTokenGenerate
+	}
+	return token, nil
+}
+
+func (cs *csrf) tokenFromCookie(r *http.Request) []byte {
+	cookie, err := r.Cookie(cs.opt.CookieName)
+	if err != nil {
+		return nil
+	}
+
+	token, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil || len(token) != csrfTokenLength {
+		return nil
+	}
+	return token
+}
+
+func (cs *csrf) saveCookie(w http.ResponseWriter, token []byte) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     cs.opt.CookieName,
+		Value:    base64.RawURLEncoding.EncodeToString(token),
+		Path:     cs.opt.CookiePath,
+		Domain:   cs.opt.CookieDomain,
+		MaxAge:   cs.opt.CookieMaxAge,
+		Secure:   cs.opt.Secure,
+		HttpOnly: cs.opt.Mode == CSRFCookie,
+		SameSite: cs.opt.SameSite,
+	})
+}
+
+func (cs *csrf) requestToken(r *http.Request) string {
+	if token := r.Header.Get(cs.opt.HeaderName); token != "" {
+		return token
+	}
+
+	if token := r.PostFormValue(cs.opt.FieldName); token != "" {
+		return token
+	}
+
+	if r.MultipartForm != nil {
+		if vals := r.MultipartForm.Value[cs.opt.FieldName]; len(vals) > 0 {
+			return vals[0]
+		}
+	}
+
+	return ""
+}
+
+func (cs *csrf) validToken(realToken []byte, sent string) bool {
+	token, err := base64.RawURLEncoding.DecodeString(sent)
+	if err != nil {
+		return false
+	}
+
+	switch len(token) {
+	case csrfTokenLength * 2:
+		token = unmaskToken(token)
+	case csrfTokenLength:
+		// An unmasked token is the cookie value, only a script can read it.
+		if cs.opt.Mode != CSRFDoubleSubmit {
+			return false
+		}
+	default:
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(realToken, token) == 1
+}
+
+// checkOrigin validates the Origin header, or the Referer header if Origin is absent.
+// A missing Referer is only rejected on TLS connections, where browsers always send it.
+func (cs *csrf) checkOrigin(r *http.Request) error {
+	if origin := r.Header.Get(server.HeaderOrigin); origin != "" {
+		u, err := url.Parse(origin)
+		if err != nil || !cs.trustedHost(r, u.Host) {
+			return errCSRFBadOrigin
+		}
+		return nil
+	}
+
+	referer := r.Referer()
+	if referer == "" {
+		if r.TLS != nil {
+			return errCSRFNoReferer
+		}
+		return nil
+	}
+
+	u, err := url.Parse(referer)
+	if err != nil || !cs.trustedHost(r, u.Host) {
+		return errCSRFBadReferer
+	}
+	if r.TLS != nil && u.Scheme != "https" {
+		return errCSRFBadReferer
+	}
+	return nil
+}
+
+func (cs *csrf) trustedHost(r *http.Request, host string) bool {
+	if strings.EqualFold(host, r.Host) {
+		return true
+	}
+
+	for _, trusted := range cs.opt.TrustedOrigins {
+		if strings.EqualFold(host, trusted) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case server.GET, server.HEAD, server.OPTIONS, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// maskToken XORs the token with a one-time pad, so the rendered token differs per
+// request and can not be recovered by compression side channels.
+func maskToken(token []byte) (string, error) {
+	pad, err := randomBytes(len(token))
+	if err != nil {
+		return "", err
+	}
+
+	masked := make([]byte, len(token)*2)
+	copy(masked, pad)
+	for i := range token {
+		masked[len(token)+i] = token[i] ^ pad[i]
+	}
+	return base64.RawURLEncoding.EncodeToString(masked), nil
+}
+
+func unmaskToken(masked []byte) []byte {
+	n := len(masked) / 2
+	token := make([]byte, n)
+	for i := 0; i < n; i++ {
+		token[i] = masked[i] ^ masked[n+i]
+	}
+	return token
+}
+
+// MemoryCSRFStore is a CSRFStore in memory, for a single instance.
+type MemoryCSRFStore struct {
+	mu        sync.Mutex
+	tokens    map[string]*memoryCSRFEntry
+	lastSweep time.Time
+}
+
+type memoryCSRFEntry struct {
+	token   []byte
+	expires time.Time
+}
+
+// NewMemoryCSRFStore creates an empty MemoryCSRFStore.
+func NewMemoryCSRFStore() *MemoryCSRFStore {
+	return &MemoryCSRFStore{
+		tokens:    make(map[string]*memoryCSRFEntry),
+		lastSweep: time.Now(),
+	}
+}
+
+// Token implements CSRFStore.
+func (s *MemoryCSRFStore) Token(session string) ([]byte, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	if e, ok := s.tokens[session]; ok && now.Before(e.expires) {
+		return e.token, nil
+	}
+	return nil, nil
+}
+
+// SaveToken implements CSRFStore.
+func (s *MemoryCSRFStore) SaveToken(session string, token []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	s.tokens[session] = &memoryCSRFEntry{token: token, expires: time.Now().Add(ttl)}
+	s.mu.Unlock()
+	return nil
+}
+
+// DeleteToken implements CSRFStore.
+func (s *MemoryCSRFStore) DeleteToken(session string) error {
+	s.mu.Lock()
+	delete(s.tokens, session)
+	s.mu.Unlock()
+	return nil
+}
+
+// sweep removes the expired tokens once per period.
+func (s *MemoryCSRFStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < csrfSweepPeriod {
+		return
+	}
+	s.lastSweep = now
+
+	for session, e := range s.tokens {
+		if !now.Before(e.expires) {
+			delete(s.tokens, session)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package middleware
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TechCatsLab/apix/http/server"
+	"github.com/urfave/negroni"
+)
+
+func TestNegroniCSRFHandler(t *testing.T) {
+	var token string
+
+	handler, err := NegroniCSRFHandler(DefaultCSRFOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := negroni.New(handler)
+	n.UseHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = server.NewContext(w, r).CSRFToken()
+	})
+
+	rec := httptest.NewRecorder()
+	n.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://example.com/form", nil))
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || token == "" {
+		t.Fatalf("token not issued: %v %q", cookies, token)
+	}
+
+	cases := []struct {
+		origin string
+		token  string
+		status int
+	}{
+		{"http://example.com", token, http.StatusOK},
+		{"http://example.com", "", http.StatusForbidden},
+		{"http://example.com", "bad", http.StatusForbidden},
+		{"http://evil.com", token, http.StatusForbidden},
+	}
+
+	for i, c := range cases {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com/form", nil)
+		req.AddCookie(cookies[0])
+		req.Header.Set(server.HeaderOrigin, c.origin)
+		req.Header.Set("X-CSRF-Token", c.token)
+
+		rec := httptest.NewRecorder()
+		n.ServeHTTP(rec, req)
+		if rec.Code != c.status {
+			t.Errorf("case %d: expected %d, got %d", i, c.status, rec.Code)
+		}
+	}
+}
+
+// csrfHandler returns a handler behind the CSRF middleware writing the token of the
+// request.
+func csrfHandler(t *testing.T, opt CSRFOptions) http.Handler {
+	handler, err := NegroniCSRFHandler(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := negroni.New(handler)
+	n.UseHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(server.NewContext(w, r).CSRFToken()))
+	})
+	return n
+}
+
+func csrfRequest(h http.Handler, method, target string, setup func(r *http.Request)) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	if setup != nil {
+		setup(req)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestNegroniCSRFHandlerChecks(t *testing.T) {
+	opt := DefaultCSRFOptions()
+	opt.TrustedOrigins = []string{"app.example.com"}
+	opt.Skipper = func(path string) bool {
+		return path == "/webhooks"
+	}
+	h := csrfHandler(t, opt)
+
+	issued := csrfRequest(h, http.MethodGet, "http://example.com/form", nil)
+	cookie, token := issued.Result().Cookies()[0], issued.Body.String()
+	if !cookie.HttpOnly {
+		t.Fatal("the token cookie is readable by scripts")
+	}
+	other := csrfRequest(h, http.MethodGet, "http://example.com/form", nil).Body.String()
+
+	cases := []struct {
+		name   string
+		path   string
+		setup  func(r *http.Request)
+		status int
+	}{
+		{"header", "/form", func(r *http.Request) {
+			r.Header.Set(server.HeaderOrigin, "http://example.com")
+			r.Header.Set(opt.HeaderName, token)
+		}, http.StatusOK},
+		{"token of another client", "/form", func(r *http.Request) {
+			r.Header.Set(opt.HeaderName, other)
+		}, http.StatusForbidden},
+		{"unmasked cookie value", "/form", func(r *http.Request) {
+			r.Header.Set(opt.HeaderName, cookie.Value)
+		}, http.StatusForbidden},
+		{"form field", "/form", func(r *http.Request) {
+			r.Body = ioutil.NopCloser(strings.NewReader(url.Values{opt.FieldName: {token}}.Encode()))
+			r.Header.Set(server.HeaderContentType, "application/x-www-form-urlencoded")
+		}, http.StatusOK},
+		{"wrong form field", "/form", func(r *http.Request) {
+			r.Body = ioutil.NopCloser(strings.NewReader(url.Values{opt.FieldName: {other}}.Encode()))
+			r.Header.Set(server.HeaderContentType, "application/x-www-form-urlencoded")
+		}, http.StatusForbidden},
+		{"trusted origin", "/form", func(r *http.Request) {
+			r.Header.Set(server.HeaderOrigin, "https://app.example.com")
+			r.Header.Set(opt.HeaderName, token)
+		}, http.StatusOK},
+		{"untrusted origin", "/form", func(r *http.Request) {
+			r.Header.Set(server.HeaderOrigin, "http://evil.com")
+			r.Header.Set(opt.HeaderName, token)
+		}, http.StatusForbidden},
+		{"trusted referer", "/form", func(r *http.Request) {
+			r.Header.Set("Referer", "http://example.com/form")
+			r.Header.Set(opt.HeaderName, token)
+		}, http.StatusOK},
+		{"untrusted referer", "/form", func(r *http.Request) {
+			r.Header.Set("Referer", "http://evil.com/form")
+			r.Header.Set(opt.HeaderName, token)
+		}, http.StatusForbidden},
+		{"missing referer over TLS", "/form", func(r *http.Request) {
+			r.TLS = &tls.ConnectionState{}
+			r.Header.Set(opt.HeaderName, token)
+		}, http.StatusForbidden},
+		{"plain text referer over TLS", "/form", func(r *http.Request) {
+			r.TLS = &tls.ConnectionState{}
+			r.Header.Set("Referer", "http://example.com/form")
+			r.Header.Set(opt.HeaderName, token)
+		}, http.StatusForbidden},
+		{"skipped path", "/webhooks", func(r *http.Request) {
+			r.Header.Set(server.HeaderOrigin, "http://evil.com")
+		}, http.StatusOK},
+	}
+
+	for _, c := range cases {
+		rec := csrfRequest(h, http.MethodPost, "http://example.com"+c.path, func(r *http.Request) {
+			r.AddCookie(cookie)
+			c.setup(r)
+		})
+		if rec.Code != c.status {
+			t.Errorf("%s: expected %d, got %d", c.name, c.status, rec.Code)
+		}
+	}
+}
+
+func TestNegroniCSRFHandlerDoubleSubmit(t *testing.T) {
+	opt := DefaultCSRFOptions()
+	opt.Mode = CSRFDoubleSubmit
+	h := csrfHandler(t, opt)
+
+	cookie := csrfRequest(h, http.MethodGet, "http://example.com/form", nil).Result().Cookies()[0]
+	if cookie.HttpOnly {
+		t.Fatal("the token cookie is not readable by scripts")
+	}
+
+	rec := csrfRequest(h, http.MethodPost, "http://example.com/form", func(r *http.Request) {
+		r.AddCookie(cookie)
+		r.Header.Set(opt.HeaderName, cookie.Value)
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("the cookie value was rejected: %d", rec.Code)
+	}
+}
+
+// countingCSRFStore counts the writes to a MemoryCSRFStore.
+type countingCSRFStore struct {
+	*MemoryCSRFStore
+	saves int
+}
+
+func (s *countingCSRFStore) SaveToken(session string, token []byte, ttl time.Duration) error {
+	s.saves++
+	return s.MemoryCSRFStore.SaveToken(session, token, ttl)
+}
+
+func TestNegroniCSRFHandlerSynchronizer(t *testing.T) {
+	store := &countingCSRFStore{MemoryCSRFStore: NewMemoryCSRFStore()}
+
+	opt := DefaultCSRFOptions()
+	opt.Mode = CSRFSynchronizer
+	opt.Store = store
+	opt.Session = func(r *http.Request) string {
+		if c, err := r.Cookie("session"); err == nil {
+			return c.Value
+		}
+		return ""
+	}
+	h := csrfHandler(t, opt)
+
+	session := func(id string) func(r *http.Request) {
+		return func(r *http.Request) {
+			r.AddCookie(&http.Cookie{Name: "session", Value: id})
+		}
+	}
+	post := func(id, token string) int {
+		return csrfRequest(h, http.MethodPost, "http://example.com/form", func(r *http.Request) {
+			if id != "" {
+				session(id)(r)
+			}
+			r.Header.Set(opt.HeaderName, token)
+		}).Code
+	}
+
+	issued := csrfRequest(h, http.MethodGet, "http://example.com/form", session("alice"))
+	if len(issued.Result().Cookies()) != 0 {
+		t.Fatalf("a cookie was issued: %v", issued.Result().Cookies())
+	}
+	alice := issued.Body.String()
+	bob := csrfRequest(h, http.MethodGet, "http://example.com/form", session("bob")).Body.String()
+
+	if alice == "" || post("alice", alice) != http.StatusOK || post("bob", bob) != http.StatusOK {
+		t.Fatal("the token of a session was rejected")
+	}
+	if post("bob", alice) != http.StatusForbidden {
+		t.Fatal("the token of another session was accepted")
+	}
+	if post("", alice) != http.StatusForbidden {
+		t.Fatal("a request without session was accepted")
+	}
+
+	again := csrfRequest(h, http.MethodGet, "http://example.com/form", session("alice")).Body.String()
+	if again == alice || post("alice", again) != http.StatusOK {
+		t.Fatal("the token of a session is not masked per request")
+	}
+
+	if store.saves != 2 {
+		t.Fatalf("the tokens of 2 sessions were saved %d times", store.saves)
+	}
+
+	store.DeleteToken("alice")
+	if post("alice", alice) != http.StatusForbidden {
+		t.Fatal("a deleted token was accepted")
+	}
+	if store.saves != 3 {
+		t.Fatalf("the token of alice was not rotated: %d saves", store.saves)
+	}
+}
+
+func TestNegroniCSRFHandlerOptions(t *testing.T) {
+	opt := DefaultCSRFOptions()
+	opt.Mode = CSRFSynchronizer
+
+	if _, err := NegroniCSRFHandler(opt); err != errCSRFNoSessionFunc {
+		t.Fatalf("a synchronizer without Session function: %v", err)
+	}
+}
@@ -0,0 +1,412 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TechCatsLab/apix/http/server"
+	"github.com/urfave/negroni"
+)
+
+// HeaderIdempotencyKey is the header carrying the key of an idempotent request, and
+// HeaderIdempotentReplayed marks a response replayed from the store.
+const (
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+const (
+	maxIdempotencyKeyLength = 255
+	idempotencySweepPeriod  = time.Minute
+)
+
+var (
+	errIdempotencyKeyTooLong = errors.New("idempotency key is too long")
+	errIdempotencyMismatch   = errors.New("idempotency key was used with another request")
+	errIdempotencyInFlight   = errors.New("a request with this idempotency key is in progress")
+	errIdempotencyBodySize   = errors.New("request body is too large for an idempotency key")
+	errIdempotencyTruncated  = errors.New("the response of this idempotency key is too large to be replayed")
+)
+
+// IdempotencyRecord is the state of an idempotency key: the fingerprint of its request,
+// and the response once it completed.
+type IdempotencyRecord struct {
+	Fingerprint string
+	// Status is 0 while the request is in flight.
+	Status int
+	Header http.Header
+	Body   []byte
+	// Truncated marks a completed response whose body was too large to be stored.
+	Truncated bool
+}
+
+// InFlight reports whether the request of the key has not completed yet.
+func (rec *IdempotencyRecord) InFlight() bool {
+	return rec.Status == 0
+}
+
+// IdempotencyStore keeps the idempotency records, it must be safe for concurrent use and
+// may be shared by the instances of a service, such as a Redis store.
+type IdempotencyStore interface {
+	// Reserve stores rec under key for ttl if the key is unknown or expired and returns
+	// nil, or returns the record of the key.
+	Reserve(key string, rec *IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, error)
+	// Save replaces the record of key with the completed one.
+	Save(key string, rec *IdempotencyRecord, ttl time.Duration) error
+	// Delete removes the record of key, so that the request can be sent again.
+	Delete(key string) error
+}
+
+// IdempotencyOptions configures the idempotency middleware.
+type IdempotencyOptions struct {
+	// Store keeps the records, a MemoryIdempotencyStore if it is nil.
+	Store IdempotencyStore
+	// TTL is the time a response is replayed, 24 hours if it is zero.
+	TTL time.Duration
+	// LockTTL is the time a key stays in flight if the instance handling it dies, 1 minute
+	// if it is zero.
+	LockTTL time.Duration
+
+	// Methods are the methods honouring the key, POST and PATCH if empty.
+	Methods []string
+	// Paths are the path prefixes honouring the key, every path if empty.
+	Paths []string
+	// Required rejects the requests to Paths with Methods without a key with 400.
+	Required bool
+
+	// Scope separates the keys of different clients, such as the subject of their token.
+	// The keys are global if it is nil.
+	Scope func(r *http.Request) string
+
+	// MaxBodyBytes is the largest response stored, the requests repeating the key of a
+	// larger one are rejected with 409 instead of replayed, 1 MB if it is zero.
+	MaxBodyBytes int
+	// MaxRequestBytes is the largest request body fingerprinted, a request with a key and
+	// a larger body is rejected with 413, 1 MB if it is zero.
+	MaxRequestBytes int64
+
+	// ErrorHandler writes the response of a rejected request, defaults to plain text with
+	// 400 for a bad key, 409 for a request in flight or a response too large to replay, and
+	// 422 for a mismatching request.
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, status int, err error)
+}
+
+// DefaultIdempotencyOptions returns the options honouring keys on POST and PATCH for 24
+// hours, stored in memory.
+func DefaultIdempotencyOptions() IdempotencyOptions {
+	return IdempotencyOptions{
+		TTL:             24 * time.Hour,
+		LockTTL:         time.Minute,
+		Methods:         []string{server.POST, server.PATCH},
+		MaxBodyBytes:    1 << 20,
+		MaxRequestBytes: 1 << 20,
+	}
+}
+
+type idempotency struct {
+	opt     IdempotencyOptions
+	methods map[string]bool
+}
+
+// NegroniIdempotencyHandler returns a middleware honouring the Idempotency-Key header: the
+// first response of a key is stored and replayed to the requests repeating the key, so
+// that a client can retry an unsafe request without applying it twice. The key is
+// released for a retry only if the handler panics or answers with a 5xx status.
+func NegroniIdempotencyHandler(opt IdempotencyOptions) negroni.Handler {
+	def := DefaultIdempotencyOptions()
+	if opt.Store == nil {
+		opt.Store = NewMemoryIdempotencyStore()
+	}
+	if opt.TTL <= 0 {
+		opt.TTL = def.TTL
+	}
+	if opt.LockTTL <= 0 {
+		opt.LockTTL = def.LockTTL
+	}
+	if len(opt.Methods) == 0 {
+		opt.Methods = def.Methods
+	}
+	if opt.MaxBodyBytes <= 0 {
+		opt.MaxBodyBytes = def.MaxBodyBytes
+	}
+	if opt.MaxRequestBytes <= 0 {
+		opt.MaxRequestBytes = def.MaxRequestBytes
+	}
+	if opt.ErrorHandler == nil {
+		opt.ErrorHandler = idempotencyErrorHandler
+	}
+
+	id := &idempotency{opt: opt, methods: make(map[string]bool)}
+	for _, method := range opt.Methods {
+		id.methods[strings.ToUpper(method)] = true
+	}
+
+	return negroni.HandlerFunc(id.handler)
+}
+
+func idempotencyErrorHandler(w http.ResponseWriter, r *http.Request, status int, err error) {
+	http.Error(w, http.StatusText(status)+": "+err.Error(), status)
+}
+
+func (id *idempotency) applies(r *http.Request) bool {
+	if !id.methods[r.Method] {
+		return false
+	}
+	if len(id.opt.Paths) == 0 {
+		return true
+	}
+
+	for _, prefix := range id.opt.Paths {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (id *idempotency) handler(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !id.applies(r) {
+		next(w, r)
+		return
+	}
+
+	key := r.Header.Get(HeaderIdempotencyKey)
+	switch {
+	case key == "" && id.opt.Required:
+		id.opt.ErrorHandler(w, r, http.StatusBadRequest, errors.New(HeaderIdempotencyKey+" header is required"))
+		return
+	case key == "":
+		next(w, r)
+		return
+	case len(key) > maxIdempotencyKeyLength:
+		id.opt.ErrorHandler(w, r, http.StatusBadRequest, errIdempotencyKeyTooLong)
+		return
+	}
+
+	fingerprint, err := fingerprintRequest(r, id.opt.MaxRequestBytes)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err == errIdempotencyBodySize {
+			status = http.StatusRequestEntityTooLarge
+		}
+		id.opt.ErrorHandler(w, r, status, err)
+		return
+	}
+
+	if id.opt.Scope != nil {
+		key = id.opt.Scope(r) + "\x00" + key
+	}
+
+	stored, err := id.opt.Store.Reserve(key, &IdempotencyRecord{Fingerprint: fingerprint}, id.opt.LockTTL)
+	if err != nil {
+		server.Logf(server.LevelError, "Idempotency store failed: %v", err)
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	if stored != nil {
+		switch {
+		case stored.Fingerprint != fingerprint:
+			id.opt.ErrorHandler(w, r, http.StatusUnprocessableEntity, errIdempotencyMismatch)
+		case stored.InFlight():
+			w.Header().Set("Retry-After", "1")
+			id.opt.ErrorHandler(w, r, http.StatusConflict, errIdempotencyInFlight)
+		case stored.Truncated:
+			id.opt.ErrorHandler(w, r, http.StatusConflict, errIdempotencyTruncated)
+		default:
+			replay(w, stored)
+		}
+		return
+	}
+
+	rw, ok := w.(negroni.ResponseWriter)
+	if !ok {
+		rw = negroni.NewResponseWriter(w)
+	}
+	iw := &idempotencyWriter{ResponseWriter: rw, max: id.opt.MaxBodyBytes}
+
+	completed := false
+	defer func() {
+		// A panic or a server error releases the key for a retry.
+		if !completed {
+			if err := id.opt.Store.Delete(key); err != nil {
+				server.Logf(server.LevelError, "Idempotency store failed: %v", err)
+			}
+		}
+	}()
+
+	next(iw, r)
+
+	if iw.status == 0 {
+		// Nothing was written, net/http answers 200.
+		iw.WriteHeader(http.StatusOK)
+	}
+	if iw.status >= http.StatusInternalServerError {
+		return
+	}
+	completed = true
+
+	// A response too large to be replayed still claims the key, the request must not be
+	// applied again.
+	rec := &IdempotencyRecord{
+		Fingerprint: fingerprint,
+		Status:      iw.status,
+		Header:      iw.header,
+		Body:        iw.body.Bytes(),
+		Truncated:   iw.overflow,
+	}
+	if rec.Truncated {
+		rec.Body = nil
+	}
+
+	// The key stays in flight until LockTTL if the record is lost.
+	if err := id.opt.Store.Save(key, rec, id.opt.TTL); err != nil {
+		server.Logf(server.LevelError, "Idempotency store failed: %v", err)
+	}
+}
+
+// fingerprintRequest hashes the method, path, query and body of r, so that reusing a key
+// on another endpoint is a mismatch. The body is hashed as it was received, up to max
+// bytes, and kept for the handler which reads it within the limits of its route.
+func fingerprintRequest(r *http.Request, max int64) (string, error) {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "?" + r.URL.RawQuery + "\n"))
+
+	body, more, err := server.PeekBody(r, max)
+	if err != nil {
+		return "", err
+	}
+	if more {
+		return "", errIdempotencyBodySize
+	}
+
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func replay(w http.ResponseWriter, rec *IdempotencyRecord) {
+	header := w.Header()
+	for key, values := range rec.Header {
+		header[key] = append([]string(nil), values...)
+	}
+	header.Set(HeaderIdempotentReplayed, "true")
+
+	w.WriteHeader(rec.Status)
+	w.Write(rec.Body)
+}
+
+// idempotencyWriter records the response while writing it.
+type idempotencyWriter struct {
+	negroni.ResponseWriter
+
+	max      int
+	status   int
+	header   http.Header
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (iw *idempotencyWriter) WriteHeader(status int) {
+	if iw.status == 0 {
+		iw.status = status
+		iw.header = make(http.Header, len(iw.Header()))
+		for key, values := range iw.Header() {
+			iw.header[key] = append([]string(nil), values...)
+		}
+	}
+	iw.ResponseWriter.WriteHeader(status)
+}
+
+func (iw *idempotencyWriter) Write(b []byte) (int, error) {
+	if iw.status == 0 {
+		iw.WriteHeader(http.StatusOK)
+	}
+
+	if !iw.overflow {
+		if iw.body.Len()+len(b) > iw.max {
+			iw.overflow = true
+			iw.body.Reset()
+		} else {
+			iw.body.Write(b)
+		}
+	}
+	return iw.ResponseWriter.Write(b)
+}
+
+// MemoryIdempotencyStore is an IdempotencyStore in memory, for a single instance.
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	records   map[string]*memoryIdempotencyEntry
+	lastSweep time.Time
+}
+
+type memoryIdempotencyEntry struct {
+	rec     *IdempotencyRecord
+	expires time.Time
+}
+
+// NewMemoryIdempotencyStore creates an empty MemoryIdempotencyStore.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		records:   make(map[string]*memoryIdempotencyEntry),
+		lastSweep: time.Now(),
+	}
+}
+
+// Reserve implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Reserve(key string, rec *IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	if e, ok := s.records[key]; ok && now.Before(e.expires) {
+		return e.rec, nil
+	}
+
+	s.records[key] = &memoryIdempotencyEntry{rec: rec, expires: now.Add(ttl)}
+	return nil, nil
+}
+
+// Save implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Save(key string, rec *IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	s.records[key] = &memoryIdempotencyEntry{rec: rec, expires: time.Now().Add(ttl)}
+	s.mu.Unlock()
+	return nil
+}
+
+// Delete implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Delete(key string) error {
+	s.mu.Lock()
+	delete(s.records, key)
+	s.mu.Unlock()
+	return nil
+}
+
+// sweep removes the expired records once per period.
+func (s *MemoryIdempotencyStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < idempotencySweepPeriod {
+		return
+	}
+	s.lastSweep = now
+
+	for key, e := range s.records {
+		if !now.Before(e.expires) {
+			delete(s.records, key)
+		}
+	}
+}
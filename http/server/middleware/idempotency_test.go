@@ -0,0 +1,219 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package middleware
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/TechCatsLab/apix/http/server"
+	"github.com/urfave/negroni"
+)
+
+func idempotentRequest(n http.Handler, method, path, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(HeaderIdempotencyKey, key)
+	}
+
+	rec := httptest.NewRecorder()
+	n.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestNegroniIdempotencyHandler(t *testing.T) {
+	var orders int32
+
+	opt := DefaultIdempotencyOptions()
+	opt.Paths = []string{"/orders"}
+
+	n := negroni.New(NegroniIdempotencyHandler(opt))
+	n.UseHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		id := atomic.AddInt32(&orders, 1)
+
+		w.Header().Set("Location", fmt.Sprintf("/orders/%d", id))
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, "order %d: %s", id, body)
+	})
+
+	first := idempotentRequest(n, http.MethodPost, "/orders", "k1", "book")
+	if first.Code != http.StatusCreated || first.Body.String() != "order 1: book" {
+		t.Fatalf("unexpected response %d %q", first.Code, first.Body.String())
+	}
+
+	replayed := idempotentRequest(n, http.MethodPost, "/orders", "k1", "book")
+	if replayed.Code != http.StatusCreated || replayed.Body.String() != "order 1: book" {
+		t.Fatalf("unexpected replay %d %q", replayed.Code, replayed.Body.String())
+	}
+	if replayed.Header().Get("Location") != "/orders/1" || replayed.Header().Get(HeaderIdempotentReplayed) != "true" {
+		t.Fatalf("unexpected replayed headers %v", replayed.Header())
+	}
+
+	if rec := idempotentRequest(n, http.MethodPost, "/orders", "k1", "pen"); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("a mismatching payload got %d", rec.Code)
+	}
+	if rec := idempotentRequest(n, http.MethodPost, "/orders", "k2", "pen"); rec.Code != http.StatusCreated {
+		t.Fatalf("another key got %d", rec.Code)
+	}
+	if rec := idempotentRequest(n, http.MethodPost, "/orders", "", "pen"); rec.Code != http.StatusCreated {
+		t.Fatalf("a request without a key got %d", rec.Code)
+	}
+	if rec := idempotentRequest(n, http.MethodPost, "/carts", "k1", "pen"); rec.Code != http.StatusCreated {
+		t.Fatalf("an unconfigured path got %d", rec.Code)
+	}
+
+	if orders != 4 {
+		t.Fatalf("%d orders were created", orders)
+	}
+}
+
+func TestNegroniIdempotencyHandlerInFlight(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	n := negroni.New(NegroniIdempotencyHandler(DefaultIdempotencyOptions()))
+	n.UseHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	done := make(chan int)
+	go func() {
+		done <- idempotentRequest(n, http.MethodPost, "/payments", "k", "10").Code
+	}()
+	<-started
+
+	if rec := idempotentRequest(n, http.MethodPost, "/payments", "k", "10"); rec.Code != http.StatusConflict {
+		t.Fatalf("a duplicate in flight got %d", rec.Code)
+	}
+
+	close(release)
+	if code := <-done; code != http.StatusAccepted {
+		t.Fatalf("unexpected status %d", code)
+	}
+	if rec := idempotentRequest(n, http.MethodPost, "/payments", "k", "10"); rec.Code != http.StatusAccepted {
+		t.Fatalf("the replay got %d", rec.Code)
+	}
+}
+
+func TestNegroniIdempotencyHandlerFailure(t *testing.T) {
+	var calls int32
+
+	n := negroni.New(NegroniIdempotencyHandler(DefaultIdempotencyOptions()))
+	n.UseHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	if rec := idempotentRequest(n, http.MethodPost, "/orders", "k", "x"); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("unexpected status %d", rec.Code)
+	}
+	if rec := idempotentRequest(n, http.MethodPost, "/orders", "k", "x"); rec.Code != http.StatusCreated {
+		t.Fatalf("the retry after a failure got %d", rec.Code)
+	}
+	if calls != 2 {
+		t.Fatalf("the handler was called %d times", calls)
+	}
+}
+
+func TestNegroniIdempotencyHandlerLargeResponse(t *testing.T) {
+	var calls int32
+
+	opt := DefaultIdempotencyOptions()
+	opt.MaxBodyBytes = 8
+
+	n := negroni.New(NegroniIdempotencyHandler(opt))
+	n.UseHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(strings.Repeat("a", 16)))
+	})
+
+	if rec := idempotentRequest(n, http.MethodPost, "/orders", "k", "x"); rec.Code != http.StatusCreated || rec.Body.Len() != 16 {
+		t.Fatalf("unexpected response %d %q", rec.Code, rec.Body.String())
+	}
+
+	for i := 0; i < 2; i++ {
+		if rec := idempotentRequest(n, http.MethodPost, "/orders", "k", "x"); rec.Code != http.StatusConflict {
+			t.Fatalf("the repeat of a large response got %d", rec.Code)
+		}
+	}
+	if rec := idempotentRequest(n, http.MethodPost, "/orders", "k", "y"); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("a mismatching request got %d", rec.Code)
+	}
+	if calls != 1 {
+		t.Fatalf("the handler was called %d times", calls)
+	}
+}
+
+func TestNegroniIdempotencyHandlerBodyLimits(t *testing.T) {
+	opt := DefaultIdempotencyOptions()
+	opt.MaxRequestBytes = 32
+
+	ep := server.NewEntrypoint(&server.Configuration{MaxBodyBytes: 8}, nil)
+	ep.AttachMiddleware(NegroniIdempotencyHandler(opt))
+
+	rt := server.NewRouter()
+	rt.Post("/uploads", func(c *server.Context) error {
+		body, err := ioutil.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+		_, err = c.Response().Write(body)
+		return err
+	}).LimitBody(server.BodyOptions{MaxBytes: 16})
+	h := ep.Handler(rt)
+
+	for _, c := range []struct {
+		key    string
+		body   string
+		status int
+	}{
+		// The limit of the route applies after the fingerprint.
+		{"k1", strings.Repeat("a", 12), http.StatusOK},
+		{"k2", strings.Repeat("a", 24), http.StatusRequestEntityTooLarge},
+		{"k3", strings.Repeat("a", 40), http.StatusRequestEntityTooLarge},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/uploads", ioutil.NopCloser(strings.NewReader(c.body)))
+		req.Header.Set(HeaderIdempotencyKey, c.key)
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != c.status {
+			t.Fatalf("%d bytes: status %d, expected %d", len(c.body), rec.Code, c.status)
+		}
+		if c.status == http.StatusOK && rec.Body.String() != c.body {
+			t.Fatalf("the handler read %q", rec.Body.String())
+		}
+	}
+}
+
+func TestMemoryIdempotencyStore(t *testing.T) {
+	s := NewMemoryIdempotencyStore()
+
+	if rec, _ := s.Reserve("k", &IdempotencyRecord{Fingerprint: "a"}, time.Millisecond); rec != nil {
+		t.Fatal("an unknown key has a record")
+	}
+	if rec, _ := s.Reserve("k", &IdempotencyRecord{Fingerprint: "b"}, time.Minute); rec == nil || rec.Fingerprint != "a" {
+		t.Fatalf("unexpected record %+v", rec)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	if rec, _ := s.Reserve("k", &IdempotencyRecord{Fingerprint: "b"}, time.Minute); rec != nil {
+		t.Fatal("an expired key has a record")
+	}
+}
@@ -6,10 +6,14 @@
 package middleware
 
 import (
+	"github.com/TechCatsLab/apix/http/server"
 	"github.com/urfave/negroni"
 )
 
-// NegroniLoggerHandler returns a logging handler.
+// NegroniLoggerHandler returns a logging handler, the requests are logged at
+// server.LevelInfo.
 func NegroniLoggerHandler() negroni.Handler {
-	return negroni.NewLogger()
+	l := negroni.NewLogger()
+	l.ALogger = server.LevelLogger(server.LevelInfo)
+	return l
 }
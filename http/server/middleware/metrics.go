@@ -0,0 +1,124 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/TechCatsLab/apix/http/server"
+	"github.com/TechCatsLab/apix/metrics"
+	"github.com/urfave/negroni"
+)
+
+// unmatchedRoute labels the requests which matched no route, so that scanning random
+// paths does not create a series per path.
+const unmatchedRoute = "unmatched"
+
+// otherMethod labels the requests of a non-standard method, for the same reason.
+const otherMethod = "OTHER"
+
+// metricsMethods are the methods labelled as they are.
+var metricsMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// MetricsOptions configures the HTTP server metrics.
+type MetricsOptions struct {
+	// Registry receives the metrics, metrics.DefaultRegistry if it is nil.
+	Registry *metrics.Registry
+	// Namespace prefixes the metric names, "http" if it is empty.
+	Namespace string
+	// DurationBuckets are the latency buckets in seconds, metrics.DefBuckets if empty.
+	DurationBuckets []float64
+	// SizeBuckets are the response size buckets in bytes.
+	SizeBuckets []float64
+}
+
+// DefaultMetricsOptions returns the options recording on metrics.DefaultRegistry.
+func DefaultMetricsOptions() MetricsOptions {
+	return MetricsOptions{
+		Registry:        metrics.DefaultRegistry,
+		Namespace:       "http",
+		DurationBuckets: metrics.DefBuckets,
+		SizeBuckets:     []float64{100, 1000, 10000, 100000, 1000000, 10000000},
+	}
+}
+
+// NegroniMetricsHandler returns a middleware recording the requests, labelled by route
+// pattern, method and status, the non-standard methods labelled OTHER:
+//
+//	http_requests_total                 counter
+//	http_requests_in_flight             gauge
+//	http_request_duration_seconds       histogram
+//	http_response_size_bytes            histogram
+//
+// Serve the registry with Router.Handle("/metrics", opt.Registry.Handler(), "GET").
+func NegroniMetricsHandler(opt MetricsOptions) negroni.Handler {
+	def := DefaultMetricsOptions()
+	if opt.Registry == nil {
+		opt.Registry = def.Registry
+	}
+	if opt.Namespace == "" {
+		opt.Namespace = def.Namespace
+	}
+	if len(opt.SizeBuckets) == 0 {
+		opt.SizeBuckets = def.SizeBuckets
+	}
+
+	var (
+		reg      = opt.Registry
+		prefix   = opt.Namespace + "_"
+		requests = reg.NewCounter(prefix+"requests_total", "Number of HTTP requests served.", "route", "method", "status")
+		inFlight = reg.NewGauge(prefix+"requests_in_flight", "Number of HTTP requests being served.")
+		duration = reg.NewHistogram(prefix+"request_duration_seconds", "Latency of HTTP requests in seconds.", opt.DurationBuckets, "route", "method", "status")
+		size     = reg.NewHistogram(prefix+"response_size_bytes", "Size of HTTP response bodies in bytes.", opt.SizeBuckets, "route", "method", "status")
+	)
+
+	fn := func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		start := time.Now()
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		rw, ok := w.(negroni.ResponseWriter)
+		if !ok {
+			rw = negroni.NewResponseWriter(w)
+		}
+
+		info := &server.RouteInfo{}
+		next(rw, server.WithRouteInfo(r, info))
+
+		route := info.Pattern
+		if route == "" {
+			route = unmatchedRoute
+		}
+
+		method := r.Method
+		if !metricsMethods[method] {
+			method = otherMethod
+		}
+
+		status := rw.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		code := strconv.Itoa(status)
+
+		requests.Inc(route, method, code)
+		duration.Observe(time.Since(start).Seconds(), route, method, code)
+		size.Observe(float64(rw.Size()), route, method, code)
+	}
+	return negroni.HandlerFunc(fn)
+}
@@ -0,0 +1,56 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package middleware
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TechCatsLab/apix/http/server"
+	"github.com/TechCatsLab/apix/metrics"
+	"github.com/urfave/negroni"
+)
+
+func TestMetricsRoutePattern(t *testing.T) {
+	reg := metrics.NewRegistry()
+
+	rt := server.NewRouter()
+	rt.Get("/users/{id}", func(c *server.Context) error {
+		return c.ServeJSON(map[string]string{"id": "1"})
+	})
+
+	n := negroni.New()
+	n.Use(NegroniMetricsHandler(MetricsOptions{Registry: reg}))
+	n.UseHandler(rt.Handler())
+
+	for _, path := range []string{"/users/1", "/users/2", "/missing"} {
+		n.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	for _, method := range []string{"PROPFIND", "X-RANDOM-1", "X-RANDOM-2"} {
+		n.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/users/1", nil))
+	}
+
+	var buf bytes.Buffer
+	reg.WriteText(&buf)
+	text := buf.String()
+
+	for _, line := range []string{
+		`http_requests_total{route="/users/{id}",method="GET",status="200"} 2`,
+		`http_requests_total{route="unmatched",method="GET",status="404"} 1`,
+		`http_requests_total{route="unmatched",method="OTHER",status="405"} 3`,
+		`http_requests_in_flight 0`,
+		`http_response_size_bytes_sum{route="/users/{id}",method="GET",status="200"} 20`,
+	} {
+		if !strings.Contains(text, line+"\n") {
+			t.Fatalf("missing %s in:\n%s", line, text)
+		}
+	}
+	if strings.Contains(text, "PROPFIND") || strings.Contains(text, "X-RANDOM") {
+		t.Fatalf("a non-standard method was labelled as it is:\n%s", text)
+	}
+}
@@ -0,0 +1,27 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/TechCatsLab/apix/http/server"
+	"github.com/urfave/negroni"
+)
+
+// NegroniRealIPHandler returns a middleware resolving the client behind the trusted
+// proxies, the result is returned by server.Context.ClientIP, Scheme and Host.
+func NegroniRealIPHandler(trustedCIDRs []string) (negroni.Handler, error) {
+	ri, err := server.NewRealIP(trustedCIDRs)
+	if err != nil {
+		return nil, err
+	}
+
+	fn := func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		next(w, server.WithForwardedInfo(r, ri.Resolve(r)))
+	}
+	return negroni.HandlerFunc(fn), nil
+}
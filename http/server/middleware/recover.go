@@ -6,24 +6,168 @@
 package middleware
 
 import (
-	"fmt"
+	"encoding/json"
+	"log"
 	"net/http"
+	"runtime"
 
+	"github.com/TechCatsLab/apix/http/server"
 	"github.com/urfave/negroni"
 )
 
+const (
+	// HeaderXRequestID is the header carrying the request identifier.
+	HeaderXRequestID = "X-Request-Id"
+
+	defaultStackSize = 8 << 10 // 8 KB
+)
+
+// PanicInformation contains the details of a recovered panic.
+type PanicInformation struct {
+	RecoveredPanic interface{}
+	Stack          []byte
+	RequestID      string
+	Request        *http.Request
+}
+
+// PanicFormatter renders a recovered panic to the client.
+type PanicFormatter interface {
+	FormatPanicError(w http.ResponseWriter, r *http.Request, info *PanicInformation)
+}
+
+// TextPanicFormatter writes a plain-text 500 response.
+type TextPanicFormatter struct{}
+
+// FormatPanicError implements PanicFormatter.
+func (TextPanicFormatter) FormatPanicError(w http.ResponseWriter, r *http.Request, info *PanicInformation) {
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+// JSONPanicFormatter writes a JSON 500 response, suitable for APIs.
+type JSONPanicFormatter struct{}
+
+// FormatPanicError implements PanicFormatter.
+func (JSONPanicFormatter) FormatPanicError(w http.ResponseWriter, r *http.Request, info *PanicInformation) {
+	body := map[string]interface{}{
+		"status":  http.StatusInternalServerError,
+		"message": http.StatusText(http.StatusInternalServerError),
+	}
+	if info.RequestID != "" {
+		body["request_id"] = info.RequestID
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(body)
+}
+
+// RecoverOptions configures the recover handler.
+type RecoverOptions struct {
+	// Logger receives the panic message and stack, they are logged with server.Logf at
+	// server.LevelError if it is nil.
+	Logger *log.Logger
+	// PrintStack controls whether the stack trace is captured and logged.
+	PrintStack bool
+	// StackAll captures the stacks of all goroutines.
+	StackAll bool
+	// StackSize is the maximum number of stack bytes captured.
+	StackSize int
+	// RequestIDHeader is the request header to read the request ID from.
+	RequestIDHeader string
+	// ReportHook is invoked for every recovered panic, e.g. to send it to an error tracker.
+	ReportHook func(info *PanicInformation)
+	// Formatter renders the error response, defaults to TextPanicFormatter.
+	Formatter PanicFormatter
+}
+
+// DefaultRecoverOptions returns the options used by NegroniRecoverHandler.
+func DefaultRecoverOptions() RecoverOptions {
+	return RecoverOptions{
+		PrintStack:      true,
+		StackSize:       defaultStackSize,
+		RequestIDHeader: HeaderXRequestID,
+		Formatter:       TextPanicFormatter{},
+	}
+}
+
 // NegroniRecoverHandler returns a handler for recover from a http request.
 func NegroniRecoverHandler() negroni.Handler {
+	return NegroniRecoverHandlerWithOptions(DefaultRecoverOptions())
+}
+
+// NegroniRecoverHandlerWithOptions returns a recover handler configured by opt.
+// http.ErrAbortHandler is re-panicked so that net/http aborts the response silently.
+func NegroniRecoverHandlerWithOptions(opt RecoverOptions) negroni.Handler {
+	if opt.StackSize <= 0 {
+		opt.StackSize = defaultStackSize
+	}
+	if opt.RequestIDHeader == "" {
+		opt.RequestIDHeader = HeaderXRequestID
+	}
+	if opt.Formatter == nil {
+		opt.Formatter = TextPanicFormatter{}
+	}
+
 	fn := func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
-		defer recoverFunc(w)
+		defer recoverFunc(w, r, &opt)
 		next.ServeHTTP(w, r)
 	}
 	return negroni.HandlerFunc(fn)
 }
 
-func recoverFunc(w http.ResponseWriter) {
-	if err := recover(); err != nil {
-		fmt.Println("Recovered from panic in http handler:", err)
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+func recoverFunc(w http.ResponseWriter, r *http.Request, opt *RecoverOptions) {
+	err := recover()
+	if err == nil {
+		return
+	}
+
+	if err == http.ErrAbortHandler {
+		panic(err)
+	}
+
+	info := &PanicInformation{
+		RecoveredPanic: err,
+		RequestID:      r.Header.Get(opt.RequestIDHeader),
+		Request:        r,
+	}
+	if info.RequestID == "" {
+		info.RequestID = w.Header().Get(opt.RequestIDHeader)
+	}
+
+	if opt.PrintStack {
+		stack := make([]byte, opt.StackSize)
+		info.Stack = stack[:runtime.Stack(stack, opt.StackAll)]
+	}
+
+	opt.logf("panic recovered: request_id=%q %s %s: %v\n%s", info.RequestID, r.Method, r.URL.Path, err, info.Stack)
+
+	if opt.ReportHook != nil {
+		report(opt, info)
+	}
+
+	if rw, ok := w.(negroni.ResponseWriter); ok && rw.Written() {
+		return
+	}
+
+	opt.Formatter.FormatPanicError(w, r, info)
+}
+
+// report invokes the report hook, a panic in the hook must not escape.
+func report(opt *RecoverOptions, info *PanicInformation) {
+	defer func() {
+		if err := recover(); err != nil {
+			opt.logf("panic in recover report hook: %v", err)
+		}
+	}()
+
+	opt.ReportHook(info)
+}
+
+func (opt *RecoverOptions) logf(format string, v ...interface{}) {
+	if opt.Logger == nil {
+		server.Logf(server.LevelError, format, v...)
+		return
 	}
+	opt.Logger.Printf(format, v...)
 }
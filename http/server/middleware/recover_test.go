@@ -0,0 +1,88 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package middleware
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/urfave/negroni"
+)
+
+func TestNegroniRecoverHandlerWithOptions(t *testing.T) {
+	var (
+		buf      bytes.Buffer
+		reported *PanicInformation
+	)
+
+	opt := DefaultRecoverOptions()
+	opt.Logger = log.New(&buf, "", 0)
+	opt.Formatter = JSONPanicFormatter{}
+	opt.ReportHook = func(info *PanicInformation) {
+		reported = info
+	}
+
+	n := negroni.New(NegroniRecoverHandlerWithOptions(opt))
+	n.UseHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderXRequestID, "abc")
+	rec := httptest.NewRecorder()
+	n.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("unexpected status %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), `"request_id":"abc"`) {
+		t.Fatalf("unexpected body %s", rec.Body.String())
+	}
+	if reported == nil || reported.RecoveredPanic != "boom" || len(reported.Stack) == 0 {
+		t.Fatalf("unexpected report %+v", reported)
+	}
+	if !strings.Contains(buf.String(), `request_id="abc"`) {
+		t.Fatalf("unexpected log %s", buf.String())
+	}
+}
+
+func TestNegroniRecoverHandlerCommitted(t *testing.T) {
+	opt := DefaultRecoverOptions()
+	opt.Logger = log.New(&bytes.Buffer{}, "", 0)
+
+	n := negroni.New(NegroniRecoverHandlerWithOptions(opt))
+	n.UseHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("partial"))
+		panic("boom")
+	})
+
+	rec := httptest.NewRecorder()
+	n.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusAccepted || rec.Body.String() != "partial" {
+		t.Fatalf("committed response was modified: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestNegroniRecoverHandlerAbort(t *testing.T) {
+	n := negroni.New(NegroniRecoverHandler())
+	n.UseHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+
+	defer func() {
+		if err := recover(); err != http.ErrAbortHandler {
+			t.Fatalf("expected ErrAbortHandler, got %v", err)
+		}
+	}()
+
+	n.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
@@ -0,0 +1,175 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/TechCatsLab/apix/http/server"
+	"github.com/urfave/negroni"
+)
+
+// NoncePlaceholder is replaced by the per-request nonce in ContentSecurityPolicy,
+// e.g. "script-src 'self' 'nonce-{nonce}'".
+const NoncePlaceholder = "{nonce}"
+
+// SecureOptions is the set of security headers sent with every response.
+// An empty value leaves the corresponding header unset.
+type SecureOptions struct {
+	// STSSeconds is the max-age of Strict-Transport-Security, 0 disables HSTS.
+	// HSTS is only sent on TLS connections.
+	STSSeconds           int64
+	STSIncludeSubdomains bool
+	STSPreload           bool
+
+	ContentSecurityPolicy string
+	ContentTypeNosniff    bool
+	FrameOptions          string
+	ReferrerPolicy        string
+	PermissionsPolicy     string
+}
+
+// SecureStrict returns the most restrictive preset, for responses which never render content.
+func SecureStrict() SecureOptions {
+	return SecureOptions{
+		STSSeconds:            63072000,
+		STSIncludeSubdomains:  true,
+		STSPreload:            true,
+		ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'; base-uri 'none'; form-action 'none'",
+		ContentTypeNosniff:    true,
+		FrameOptions:          "DENY",
+		ReferrerPolicy:        "no-referrer",
+		PermissionsPolicy:     "accelerometer=(), camera=(), geolocation=(), gyroscope=(), magnetometer=(), microphone=(), payment=(), usb=()",
+	}
+}
+
+// SecureAPI returns the preset for JSON APIs.
+func SecureAPI() SecureOptions {
+	return SecureOptions{
+		STSSeconds:            31536000,
+		STSIncludeSubdomains:  true,
+		ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'",
+		ContentTypeNosniff:    true,
+		FrameOptions:          "DENY",
+		ReferrerPolicy:        "no-referrer",
+	}
+}
+
+// SecureWebApp returns the preset for server rendered web applications,
+// scripts must carry the nonce returned by server.Context.CSPNonce.
+func SecureWebApp() SecureOptions {
+	return SecureOptions{
+		STSSeconds:            31536000,
+		STSIncludeSubdomains:  true,
+		ContentSecurityPolicy: "default-src 'self'; script-src 'self' 'nonce-" + NoncePlaceholder + "'; style-src 'self' 'nonce-" + NoncePlaceholder + "'; object-src 'none'; base-uri 'self'; frame-ancestors 'self'",
+		ContentTypeNosniff:    true,
+		FrameOptions:          "SAMEORIGIN",
+		ReferrerPolicy:        "strict-origin-when-cross-origin",
+		PermissionsPolicy:     "camera=(), geolocation=(), microphone=()",
+	}
+}
+
+func (opt *SecureOptions) needsNonce() bool {
+	return strings.Contains(opt.ContentSecurityPolicy, NoncePlaceholder)
+}
+
+func (opt *SecureOptions) stsValue() string {
+	v := "max-age=" + strconv.FormatInt(opt.STSSeconds, 10)
+	if opt.STSIncludeSubdomains {
+		v += "; includeSubDomains"
+	}
+	if opt.STSPreload {
+		v += "; preload"
+	}
+	return v
+}
+
+// apply writes the headers to h, headers with an empty value are removed.
+func (opt *SecureOptions) apply(h http.Header, r *http.Request, nonce string) {
+	set := func(key, val string) {
+		if val == "" {
+			h.Del(key)
+			return
+		}
+		h.Set(key, val)
+	}
+
+	if opt.STSSeconds > 0 && r.TLS != nil {
+		h.Set(server.HeaderStrictTransportSecurity, opt.stsValue())
+	} else {
+		h.Del(server.HeaderStrictTransportSecurity)
+	}
+
+	set(server.HeaderContentSecurityPolicy, strings.Replace(opt.ContentSecurityPolicy, NoncePlaceholder, nonce, -1))
+	if opt.ContentTypeNosniff {
+		h.Set(server.HeaderXContentTypeOptions, "nosniff")
+	} else {
+		h.Del(server.HeaderXContentTypeOptions)
+	}
+	set(server.HeaderXFrameOptions, opt.FrameOptions)
+	set(server.HeaderReferrerPolicy, opt.ReferrerPolicy)
+	set(server.HeaderPermissionsPolicy, opt.PermissionsPolicy)
+}
+
+// NegroniSecureHandler returns a middleware which sets the security headers of opt.
+// A CSP nonce is generated per request when the policy contains NoncePlaceholder.
+func NegroniSecureHandler(opt SecureOptions, skipper Skipper) negroni.Handler {
+	if skipper == nil {
+		skipper = defaulSkiper
+	}
+
+	fn := func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		if skipper(r.URL.Path) {
+			next(w, r)
+			return
+		}
+
+		var nonce string
+		if opt.needsNonce() {
+			var err error
+			if nonce, err = newNonce(); err != nil {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			r = server.WithCSPNonce(r, nonce)
+		}
+
+		opt.apply(w.Header(), r, nonce)
+		next(w, r)
+	}
+	return negroni.HandlerFunc(fn)
+}
+
+// SecureRouteFilter returns a filter which replaces the security headers of a single route by opt.
+// It reuses the nonce generated by NegroniSecureHandler, or generates one if there is none.
+func SecureRouteFilter(opt SecureOptions) server.FilterFunc {
+	return func(c *server.Context) bool {
+		nonce := c.CSPNonce()
+		if nonce == "" && opt.needsNonce() {
+			var err error
+			if nonce, err = newNonce(); err != nil {
+				c.LastError = err
+				return false
+			}
+			c.SetRequest(server.WithCSPNonce(c.Request(), nonce))
+		}
+
+		opt.apply(c.Response().Header(), c.Request(), nonce)
+		return true
+	}
+}
+
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,169 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package middleware
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TechCatsLab/apix/http/server"
+	"github.com/urfave/negroni"
+)
+
+// secureServer serves the CSP nonce of each request behind the secure middleware.
+func secureServer(opt SecureOptions, skipper Skipper, filters ...server.FilterFunc) http.Handler {
+	rt := server.NewRouter()
+	rt.Get("/", func(c *server.Context) error {
+		_, err := io.WriteString(c.Response(), c.CSPNonce())
+		return err
+	}, filters...)
+	rt.Get("/skip", func(c *server.Context) error {
+		return c.WriteHeader(http.StatusOK)
+	})
+
+	n := negroni.New(NegroniSecureHandler(opt, skipper))
+	n.UseHandler(rt)
+	return n
+}
+
+func secureRequest(h http.Handler, path string, https bool) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if https {
+		req.TLS = &tls.ConnectionState{}
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestSecureHandlerPresets(t *testing.T) {
+	cases := []struct {
+		name    string
+		opt     SecureOptions
+		headers map[string]string
+	}{
+		{"strict", SecureStrict(), map[string]string{
+			server.HeaderStrictTransportSecurity: "max-age=63072000; includeSubDomains; preload",
+			server.HeaderContentSecurityPolicy:   "default-src 'none'; frame-ancestors 'none'; base-uri 'none'; form-action 'none'",
+			server.HeaderXContentTypeOptions:     "nosniff",
+			server.HeaderXFrameOptions:           "DENY",
+			server.HeaderReferrerPolicy:          "no-referrer",
+			server.HeaderPermissionsPolicy:       "accelerometer=(), camera=(), geolocation=(), gyroscope=(), magnetometer=(), microphone=(), payment=(), usb=()",
+		}},
+		{"api", SecureAPI(), map[string]string{
+			server.HeaderStrictTransportSecurity: "max-age=31536000; includeSubDomains",
+			server.HeaderContentSecurityPolicy:   "default-src 'none'; frame-ancestors 'none'",
+			server.HeaderXContentTypeOptions:     "nosniff",
+			server.HeaderXFrameOptions:           "DENY",
+			server.HeaderReferrerPolicy:          "no-referrer",
+			server.HeaderPermissionsPolicy:       "",
+		}},
+		{"webapp", SecureWebApp(), map[string]string{
+			server.HeaderStrictTransportSecurity: "max-age=31536000; includeSubDomains",
+			server.HeaderContentSecurityPolicy:   "default-src 'self'; script-src 'self' 'nonce-{nonce}'; style-src 'self' 'nonce-{nonce}'; object-src 'none'; base-uri 'self'; frame-ancestors 'self'",
+			server.HeaderXContentTypeOptions:     "nosniff",
+			server.HeaderXFrameOptions:           "SAMEORIGIN",
+			server.HeaderReferrerPolicy:          "strict-origin-when-cross-origin",
+			server.HeaderPermissionsPolicy:       "camera=(), geolocation=(), microphone=()",
+		}},
+	}
+
+	for _, c := range cases {
+		rec := secureRequest(secureServer(c.opt, nil), "/", true)
+		nonce := rec.Body.String()
+
+		for key, want := range c.headers {
+			want = strings.Replace(want, NoncePlaceholder, nonce, -1)
+			if got := rec.Header().Get(key); got != want {
+				t.Fatalf("%s: %s is %q, want %q", c.name, key, got, want)
+			}
+		}
+
+		// HSTS is only sent over TLS.
+		rec = secureRequest(secureServer(c.opt, nil), "/", false)
+		if v := rec.Header().Get(server.HeaderStrictTransportSecurity); v != "" {
+			t.Fatalf("%s: HSTS sent without TLS: %q", c.name, v)
+		}
+		if rec.Header().Get(server.HeaderXContentTypeOptions) != "nosniff" {
+			t.Fatalf("%s: the other headers were not sent without TLS", c.name)
+		}
+	}
+}
+
+func TestSecureHandlerNonce(t *testing.T) {
+	h := secureServer(SecureWebApp(), nil)
+
+	seen := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		rec := secureRequest(h, "/", true)
+
+		nonce := rec.Body.String()
+		if nonce == "" || seen[nonce] {
+			t.Fatalf("the nonce %q is not fresh", nonce)
+		}
+		seen[nonce] = true
+
+		if csp := rec.Header().Get(server.HeaderContentSecurityPolicy); !strings.Contains(csp, "'nonce-"+nonce+"'") || strings.Contains(csp, NoncePlaceholder) {
+			t.Fatalf("the policy %q does not carry the nonce %q", csp, nonce)
+		}
+	}
+
+	if rec := secureRequest(secureServer(SecureAPI(), nil), "/", true); rec.Body.Len() != 0 {
+		t.Fatalf("a nonce was generated for a policy without placeholder: %q", rec.Body.String())
+	}
+}
+
+func TestSecureHandlerSkipper(t *testing.T) {
+	h := secureServer(SecureStrict(), func(path string) bool {
+		return path == "/skip"
+	})
+
+	rec := secureRequest(h, "/skip", true)
+	for _, key := range []string{server.HeaderStrictTransportSecurity, server.HeaderContentSecurityPolicy, server.HeaderXFrameOptions} {
+		if v := rec.Header().Get(key); v != "" {
+			t.Fatalf("a skipped path got %s: %q", key, v)
+		}
+	}
+
+	if rec := secureRequest(h, "/", true); rec.Header().Get(server.HeaderXFrameOptions) != "DENY" {
+		t.Fatal("a path which is not skipped got no headers")
+	}
+}
+
+func TestSecureRouteFilter(t *testing.T) {
+	// The route of a web page behind an API preset.
+	rec := secureRequest(secureServer(SecureAPI(), nil, SecureRouteFilter(SecureWebApp())), "/", true)
+
+	nonce := rec.Body.String()
+	if nonce == "" || !strings.Contains(rec.Header().Get(server.HeaderContentSecurityPolicy), "'nonce-"+nonce+"'") {
+		t.Fatalf("the filter did not generate the nonce: %q %q", nonce, rec.Header().Get(server.HeaderContentSecurityPolicy))
+	}
+	if v := rec.Header().Get(server.HeaderXFrameOptions); v != "SAMEORIGIN" {
+		t.Fatalf("the route kept the frame options %q", v)
+	}
+	if v := rec.Header().Get(server.HeaderPermissionsPolicy); v != "camera=(), geolocation=(), microphone=()" {
+		t.Fatalf("unexpected permissions policy %q", v)
+	}
+
+	// The filter reuses the nonce of the middleware, and removes the headers it leaves empty.
+	opt := SecureOptions{ContentSecurityPolicy: "script-src 'nonce-" + NoncePlaceholder + "'"}
+	rec = secureRequest(secureServer(SecureWebApp(), nil, SecureRouteFilter(opt)), "/", true)
+
+	nonce = rec.Body.String()
+	if csp := rec.Header().Get(server.HeaderContentSecurityPolicy); csp != "script-src 'nonce-"+nonce+"'" {
+		t.Fatalf("the filter did not reuse the nonce %q: %q", nonce, csp)
+	}
+	for _, key := range []string{server.HeaderStrictTransportSecurity, server.HeaderXContentTypeOptions, server.HeaderXFrameOptions} {
+		if v := rec.Header().Get(key); v != "" {
+			t.Fatalf("the filter kept %s: %q", key, v)
+		}
+	}
+}
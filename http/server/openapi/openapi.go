@@ -0,0 +1,359 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+// Package openapi generates an OpenAPI 3 document from the routes of a server.Router and
+// the Operation they are described with.
+//
+//	rt.Get("/users/{id:[0-9]+}", getUser).Describe(server.Operation{
+//		Summary:   "Get a user",
+//		Responses: map[int]interface{}{200: User{}, 404: nil},
+//	})
+//	rt.Handle("/openapi.json", openapi.Handler(rt, openapi.Info{Title: "Users", Version: "1.0.0"}), "GET")
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/TechCatsLab/apix/http/server"
+)
+
+// Version is the OpenAPI version of the generated documents.
+const Version = "3.0.3"
+
+// pathParam matches the variables of a mux pattern, such as {id} or {id:[0-9]+}.
+var pathParam = regexp.MustCompile(`\{([^{}:]+)(?::((?:[^{}]|\{[^{}]*\})*))?\}`)
+
+// Info is the metadata of the API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server is a base URL of the API.
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Servers    []Server            `json:"servers,omitempty"`
+	Tags       []Tag               `json:"tags,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components *Components         `json:"components,omitempty"`
+}
+
+// Tag groups operations.
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// PathItem holds the operations of a path by lower case method.
+type PathItem map[string]*Operation
+
+// Operation is an API operation on a path.
+type Operation struct {
+	OperationID string               `json:"operationId,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Deprecated  bool                 `json:"deprecated,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter is a path, query, header or cookie parameter.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is the body of a request.
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is a response of an operation.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType is the schema of a body.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the schemas referenced by the operations.
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+// Generate returns the document of the described routes of rt. Routes without methods,
+// such as the ones served by a http.Handler for every method, and hidden routes are
+// left out. The versions of a route sharing its path are documented by the operation of
+// the newest one, with the version header as a parameter if there is one.
+func Generate(rt *server.Router, info Info, servers ...Server) *Document {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Servers: servers,
+		Paths:   make(map[string]PathItem),
+	}
+
+	g := newGenerator()
+	tags := make(map[string]bool)
+	routes := rt.Routes()
+	versioning := rt.Versioning()
+	shared := sharedVersions(routes, versioning)
+
+	for _, route := range routes {
+		if !documented(route) {
+			continue
+		}
+
+		op := route.Operation
+		if op == nil {
+			op = &server.Operation{}
+		}
+
+		versions := shared[route.Methods[0]+" "+route.Pattern]
+		if len(versions) > 0 && route.Version != versions[len(versions)-1] {
+			continue
+		}
+
+		path, params := g.pathParameters(route.Pattern, op.Parameters)
+		if p := versionParameter(versions, versioning); len(versions) > 0 && p != nil {
+			params = append(params, p)
+		}
+
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = make(PathItem)
+			doc.Paths[path] = item
+		}
+
+		for _, method := range route.Methods {
+			o := g.operation(op, params)
+			if op.OperationID != "" && len(route.Methods) > 1 {
+				o.OperationID = op.OperationID + method[:1] + strings.ToLower(method[1:])
+			}
+			item[strings.ToLower(method)] = o
+		}
+
+		for _, tag := range op.Tags {
+			tags[tag] = true
+		}
+	}
+
+	for tag := range tags {
+		doc.Tags = append(doc.Tags, Tag{Name: tag})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool {
+		return doc.Tags[i].Name < doc.Tags[j].Name
+	})
+
+	if len(g.schemas) > 0 {
+		doc.Components = &Components{Schemas: g.schemas}
+	}
+
+	return doc
+}
+
+func documented(route server.Route) bool {
+	return len(route.Methods) > 0 && (route.Operation == nil || !route.Operation.Hidden)
+}
+
+// sharedVersions returns the sorted versions of the documented versioned routes by method
+// and pattern, which are shared by the versions without path prefix.
+func sharedVersions(routes []server.Route, opt server.VersionOptions) map[string][]int {
+	shared := make(map[string][]int)
+	if opt.PathPrefix {
+		return shared
+	}
+
+	for _, route := range routes {
+		if route.Version > 0 && documented(route) {
+			key := route.Methods[0] + " " + route.Pattern
+			shared[key] = append(shared[key], route.Version)
+		}
+	}
+	for _, versions := range shared {
+		sort.Ints(versions)
+	}
+
+	return shared
+}
+
+// versionParameter documents the version header selecting one of versions, it returns
+// nil if the version is not read from a header.
+func versionParameter(versions []int, opt server.VersionOptions) *Parameter {
+	if opt.Header == "" {
+		return nil
+	}
+
+	enum := make([]interface{}, len(versions))
+	for i, v := range versions {
+		enum[i] = v
+	}
+
+	return &Parameter{
+		Name:        opt.Header,
+		In:          "header",
+		Description: "API version, the newest if it is missing.",
+		Schema:      &Schema{Type: "integer", Enum: enum},
+	}
+}
+
+// pathParameters converts a mux pattern to an OpenAPI path, and returns its parameters
+// with the documented ones.
+func (g *generator) pathParameters(pattern string, documented []server.Parameter) (string, []*Parameter) {
+	var params []*Parameter
+	seen := make(map[string]bool)
+
+	for _, p := range documented {
+		if p.In == "path" {
+			seen[p.Name] = true
+		}
+	}
+
+	path := pathParam.ReplaceAllStringFunc(pattern, func(m string) string {
+		sub := pathParam.FindStringSubmatch(m)
+		name := strings.TrimSpace(sub[1])
+
+		if !seen[name] {
+			schema := &Schema{Type: "string"}
+			if sub[2] != "" {
+				schema.Pattern = "^" + sub[2] + "$"
+			}
+			params = append(params, &Parameter{Name: name, In: "path", Required: true, Schema: schema})
+			seen[name] = true
+		}
+		return "{" + name + "}"
+	})
+
+	for _, p := range documented {
+		var schema *Schema
+		if p.Type == nil {
+			schema = &Schema{Type: "string"}
+		} else {
+			schema = g.schemaOf(p.Type)
+		}
+
+		params = append(params, &Parameter{
+			Name:        p.Name,
+			In:          p.In,
+			Description: p.Description,
+			Required:    p.Required || p.In == "path",
+			Schema:      schema,
+		})
+	}
+
+	return path, params
+}
+
+func (g *generator) operation(op *server.Operation, params []*Parameter) *Operation {
+	o := &Operation{
+		OperationID: op.OperationID,
+		Summary:     op.Summary,
+		Description: op.Description,
+		Tags:        op.Tags,
+		Deprecated:  op.Deprecated,
+		Parameters:  params,
+		Responses:   make(map[string]*Response),
+	}
+
+	if op.Request != nil {
+		o.RequestBody = &RequestBody{
+			Required: true,
+			Content:  jsonContent(g.schemaOf(op.Request)),
+		}
+	}
+
+	for status, v := range op.Responses {
+		resp := &Response{Description: http.StatusText(status)}
+		if resp.Description == "" {
+			resp.Description = strconv.Itoa(status)
+		}
+		if v != nil {
+			resp.Content = jsonContent(g.schemaOf(v))
+		}
+		o.Responses[strconv.Itoa(status)] = resp
+	}
+
+	if len(o.Responses) == 0 {
+		o.Responses["default"] = &Response{Description: "Response"}
+	}
+
+	return o
+}
+
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{server.MIMEApplicationJSON: {Schema: schema}}
+}
+
+// JSON returns the document encoded in JSON.
+func (d *Document) JSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// YAML returns the document encoded in YAML.
+func (d *Document) YAML() ([]byte, error) {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	return jsonToYAML(b)
+}
+
+// Handler serves the document of rt, generated on the first request once the routes are
+// registered. It is served in YAML if the path ends with .yaml or .yml, or if the Accept
+// header asks for it, and in JSON otherwise.
+func Handler(rt *server.Router, info Info, servers ...Server) http.Handler {
+	var (
+		once      sync.Once
+		jsonDoc   []byte
+		yamlDoc   []byte
+		encodeErr error
+	)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() {
+			doc := Generate(rt, info, servers...)
+			if jsonDoc, encodeErr = doc.JSON(); encodeErr != nil {
+				return
+			}
+			yamlDoc, encodeErr = doc.YAML()
+		})
+
+		if encodeErr != nil {
+			http.Error(w, encodeErr.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if strings.HasSuffix(r.URL.Path, ".yaml") || strings.HasSuffix(r.URL.Path, ".yml") || strings.Contains(r.Header.Get(server.HeaderAccept), "yaml") {
+			w.Header().Set(server.HeaderContentType, "application/yaml; charset=utf-8")
+			w.Write(yamlDoc)
+			return
+		}
+
+		w.Header().Set(server.HeaderContentType, server.MIMEApplicationJSONCharsetUTF8)
+		w.Write(jsonDoc)
+	})
+}
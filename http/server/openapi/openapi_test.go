@@ -0,0 +1,191 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TechCatsLab/apix/http/server"
+)
+
+type address struct {
+	City string `json:"city" validate:"required"`
+}
+
+type createUser struct {
+	Name     string            `json:"name" validate:"required,min=2,max=32" doc:"Display name"`
+	Email    string            `json:"email" validate:"required,email"`
+	Age      int               `json:"age,omitempty" validate:"gte=0,lt=150"`
+	Role     string            `json:"role" validate:"eq=admin|eq=user"`
+	Plan     string            `json:"plan" validate:"oneof=free pro"`
+	Tags     []string          `json:"tags" validate:"max=5,dive,alphanum"`
+	Address  *address          `json:"address"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Created  time.Time         `json:"created"`
+	password string
+	Ignored  string `json:"-"`
+}
+
+type user struct {
+	ID int64 `json:"id"`
+	createUser
+	Friends []*user `json:"friends,omitempty"`
+}
+
+func testRouter() *server.Router {
+	rt := server.NewRouter()
+	noop := func(c *server.Context) error { return nil }
+
+	rt.Post("/users", noop).Describe(server.Operation{
+		OperationID: "createUser",
+		Summary:     "Create a user",
+		Tags:        []string{"users"},
+		Request:     createUser{},
+		Responses:   map[int]interface{}{201: user{}, 409: nil},
+	})
+	rt.Get("/users/{id:[0-9]+}", noop).Describe(server.Operation{
+		Tags:       []string{"users"},
+		Parameters: []server.Parameter{{Name: "fields", In: "query", Type: []string{}}},
+		Responses:  map[int]interface{}{200: &user{}},
+	})
+	rt.Get("/internal", noop).Describe(server.Operation{Hidden: true})
+	rt.Handle("/debug/", http.NotFoundHandler())
+
+	return rt
+}
+
+func TestGenerate(t *testing.T) {
+	doc := Generate(testRouter(), Info{Title: "Users", Version: "1.0.0"})
+
+	if _, ok := doc.Paths["/internal"]; ok {
+		t.Fatal("a hidden route is documented")
+	}
+
+	get := doc.Paths["/users/{id}"]["get"]
+	if get == nil || len(get.Parameters) != 2 {
+		t.Fatalf("unexpected operation %+v", get)
+	}
+	if p := get.Parameters[0]; p.Name != "id" || p.In != "path" || !p.Required || p.Schema.Pattern != "^[0-9]+$" {
+		t.Fatalf("unexpected path parameter %+v", p)
+	}
+	if p := get.Parameters[1]; p.Schema.Type != "array" || p.Schema.Items.Type != "string" {
+		t.Fatalf("unexpected query parameter %+v", p)
+	}
+
+	post := doc.Paths["/users"]["post"]
+	if post.OperationID != "createUser" || post.Responses["409"].Content != nil {
+		t.Fatalf("unexpected operation %+v", post)
+	}
+	if ref := post.RequestBody.Content["application/json"].Schema.Ref; ref != "#/components/schemas/createUser" {
+		t.Fatalf("unexpected request schema %s", ref)
+	}
+
+	s := doc.Components.Schemas["createUser"]
+	if !reflect.DeepEqual(s.Required, []string{"name", "email"}) {
+		t.Fatalf("unexpected required %v", s.Required)
+	}
+	if _, ok := s.Properties["password"]; ok {
+		t.Fatal("an unexported field is documented")
+	}
+	if _, ok := s.Properties["Ignored"]; ok {
+		t.Fatal("an ignored field is documented")
+	}
+
+	name := s.Properties["name"]
+	if *name.MinLength != 2 || *name.MaxLength != 32 || name.Description != "Display name" {
+		t.Fatalf("unexpected name schema %+v", name)
+	}
+	if s.Properties["email"].Format != "email" {
+		t.Fatal("email format is missing")
+	}
+	if age := s.Properties["age"]; *age.Minimum != 0 || *age.Maximum != 150 || !age.ExclusiveMaximum {
+		t.Fatalf("unexpected age schema %+v", age)
+	}
+	if !reflect.DeepEqual(s.Properties["role"].Enum, []interface{}{"admin", "user"}) {
+		t.Fatalf("unexpected role enum %v", s.Properties["role"].Enum)
+	}
+	if !reflect.DeepEqual(s.Properties["plan"].Enum, []interface{}{"free", "pro"}) {
+		t.Fatalf("unexpected plan enum %v", s.Properties["plan"].Enum)
+	}
+	if tags := s.Properties["tags"]; *tags.MaxItems != 5 || tags.Items.Pattern == "" {
+		t.Fatalf("unexpected tags schema %+v", tags)
+	}
+	if s.Properties["created"].Format != "date-time" {
+		t.Fatal("time is not a date-time")
+	}
+
+	u := doc.Components.Schemas["user"]
+	if _, ok := u.Properties["email"]; !ok {
+		t.Fatal("embedded fields are missing")
+	}
+	if u.Properties["friends"].Items.Ref != "#/components/schemas/user" {
+		t.Fatal("recursive type is not referenced")
+	}
+}
+
+func TestGenerateVersions(t *testing.T) {
+	noop := func(c *server.Context) error { return nil }
+
+	for _, prefix := range []bool{false, true} {
+		rt := server.NewRouter()
+		rt.SetVersioning(server.VersionOptions{PathPrefix: prefix, Header: "X-API-Version"})
+
+		rt.Version(1).Get("/users/{id}", noop).Describe(server.Operation{OperationID: "getUserV1", Deprecated: true})
+		rt.Version(2).Get("/users/{id}", noop).Describe(server.Operation{OperationID: "getUserV2"})
+
+		doc := Generate(rt, Info{Title: "Users", Version: "2.0.0"})
+
+		if prefix {
+			if len(doc.Paths) != 2 || doc.Paths["/v1/users/{id}"]["get"].OperationID != "getUserV1" || doc.Paths["/v2/users/{id}"]["get"].OperationID != "getUserV2" {
+				t.Fatalf("unexpected prefixed paths %v", doc.Paths)
+			}
+			continue
+		}
+
+		if len(doc.Paths) != 1 {
+			t.Fatalf("unexpected paths %v", doc.Paths)
+		}
+		get := doc.Paths["/users/{id}"]["get"]
+		if get == nil || get.OperationID != "getUserV2" || get.Deprecated || len(get.Parameters) != 2 {
+			t.Fatalf("unexpected operation %+v", get)
+		}
+		if p := get.Parameters[1]; p.Name != "X-API-Version" || p.In != "header" || !reflect.DeepEqual(p.Schema.Enum, []interface{}{1, 2}) {
+			t.Fatalf("unexpected version parameter %+v", p)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	rt := testRouter()
+	h := Handler(rt, Info{Title: "Users", Version: "1.0.0"})
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/openapi.yaml", nil))
+
+	body := rec.Body.String()
+	for _, line := range []string{
+		`openapi: "3.0.3"`,
+		`  "/users/{id}":`,
+		`      - in: "path"`,
+		`        name: "id"`,
+		`          $ref: "#/components/schemas/user"`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Fatalf("missing %q in:\n%s", line, body)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/openapi.json", nil))
+	if !strings.Contains(rec.Body.String(), `"openapi": "3.0.3"`) {
+		t.Fatalf("unexpected JSON document:\n%s", rec.Body.String())
+	}
+}
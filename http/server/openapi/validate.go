@@ -0,0 +1,200 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// validateFormats are the validator tags with an OpenAPI format.
+	validateFormats = map[string]string{
+		"email":    "email",
+		"url":      "uri",
+		"uri":      "uri",
+		"uuid":     "uuid",
+		"uuid4":    "uuid",
+		"ipv4":     "ipv4",
+		"ipv6":     "ipv6",
+		"hostname": "hostname",
+	}
+
+	// validatePatterns are the validator tags with an equivalent regular expression.
+	validatePatterns = map[string]string{
+		"alpha":    "^[a-zA-Z]+$",
+		"alphanum": "^[a-zA-Z0-9]+$",
+		"numeric":  `^[-+]?[0-9]+(?:\.[0-9]+)?$`,
+	}
+)
+
+// applyValidate sets the constraints of a validate tag, as used by Context.Validate, on
+// the schema s of a field of type t. The rules after "dive" apply to the items of slices
+// and maps. It reports whether the field is required.
+func applyValidate(s *Schema, t reflect.Type, tag string) bool {
+	if tag == "" || tag == "-" {
+		return false
+	}
+
+	var (
+		required bool
+		target   = s
+		typ      = t
+	)
+
+	for _, rule := range strings.Split(tag, ",") {
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+
+		switch rule {
+		case "required":
+			if target == s {
+				required = true
+			}
+			continue
+		case "dive":
+			if typ.Kind() != reflect.Slice && typ.Kind() != reflect.Array && typ.Kind() != reflect.Map {
+				return required
+			}
+
+			typ = typ.Elem()
+			if target.Items != nil {
+				target = target.Items
+			} else if target.AdditionalProperties != nil {
+				target = target.AdditionalProperties
+			} else {
+				return required
+			}
+			continue
+		case "keys", "endkeys", "omitempty":
+			continue
+		}
+
+		// A referenced component is shared, its constraints are its own.
+		if target.Ref != "" {
+			continue
+		}
+
+		if strings.Contains(rule, "|") {
+			applyAlternatives(target, typ, strings.Split(rule, "|"))
+			continue
+		}
+
+		name, param := rule, ""
+		if i := strings.Index(rule, "="); i >= 0 {
+			name, param = rule[:i], rule[i+1:]
+		}
+		applyRule(target, typ, name, param)
+	}
+
+	return required
+}
+
+// applyAlternatives turns eq=a|eq=b into an enum, other alternatives are not documented.
+func applyAlternatives(s *Schema, t reflect.Type, rules []string) {
+	var enum []interface{}
+	for _, rule := range rules {
+		if !strings.HasPrefix(rule, "eq=") {
+			return
+		}
+		enum = append(enum, enumValue(t, strings.TrimPrefix(rule, "eq=")))
+	}
+	s.Enum = enum
+}
+
+func applyRule(s *Schema, t reflect.Type, name, param string) {
+	if format, ok := validateFormats[name]; ok {
+		s.Format = format
+		return
+	}
+	if pattern, ok := validatePatterns[name]; ok {
+		s.Pattern = pattern
+		return
+	}
+
+	switch name {
+	case "oneof":
+		s.Enum = nil
+		for _, v := range strings.Fields(param) {
+			s.Enum = append(s.Enum, enumValue(t, v))
+		}
+		return
+	case "eq":
+		s.Enum = []interface{}{enumValue(t, param)}
+		return
+	}
+
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		applyBounds(name, n, &s.MinLength, &s.MaxLength)
+	case reflect.Slice, reflect.Array:
+		applyBounds(name, n, &s.MinItems, &s.MaxItems)
+	case reflect.Map:
+		applyBounds(name, n, &s.MinProperties, &s.MaxProperties)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		switch name {
+		case "min", "gte":
+			s.Minimum, s.ExclusiveMinimum = float(n), false
+		case "gt":
+			s.Minimum, s.ExclusiveMinimum = float(n), true
+		case "max", "lte":
+			s.Maximum, s.ExclusiveMaximum = float(n), false
+		case "lt":
+			s.Maximum, s.ExclusiveMaximum = float(n), true
+		case "len":
+			s.Minimum, s.Maximum = float(n), float(n)
+		}
+	}
+}
+
+// applyBounds sets the length bounds, validator counts gt and lt on lengths as well.
+func applyBounds(name string, n float64, min, max **int64) {
+	v := int64(n)
+
+	switch name {
+	case "min", "gte":
+		*min = &v
+	case "gt":
+		v++
+		*min = &v
+	case "max", "lte":
+		*max = &v
+	case "lt":
+		v--
+		*max = &v
+	case "len":
+		*min, *max = &v, &v
+	}
+}
+
+// enumValue converts an enum value of a validate tag to the type of the field.
+func enumValue(t reflect.Type, v string) interface{} {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	case reflect.Float32, reflect.Float64:
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return v
+}
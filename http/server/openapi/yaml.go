@@ -0,0 +1,118 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// plainKey matches the keys written without quotes.
+var plainKey = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$.\-]*$`)
+
+// yamlKeywords would be read as booleans or null if they were not quoted.
+var yamlKeywords = map[string]bool{
+	"y": true, "n": true, "yes": true, "no": true, "on": true, "off": true,
+	"true": true, "false": true, "null": true,
+}
+
+// jsonToYAML converts a JSON document to block style YAML, with sorted keys. Strings are
+// double quoted, so that the JSON escapes are valid YAML.
+func jsonToYAML(b []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	writeYAML(&buf, v, 0)
+	return buf.Bytes(), nil
+}
+
+func writeYAML(buf *bytes.Buffer, v interface{}, indent int) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			buf.WriteString(" {}\n")
+			return
+		}
+		if indent > 0 {
+			buf.WriteByte('\n')
+		}
+		writeMapEntries(buf, v, indent, false)
+	case []interface{}:
+		if len(v) == 0 {
+			buf.WriteString(" []\n")
+			return
+		}
+		buf.WriteByte('\n')
+		for _, item := range v {
+			buf.WriteString(strings.Repeat("  ", indent) + "-")
+			if m, ok := item.(map[string]interface{}); ok && len(m) > 0 {
+				// The first entry shares the line of the dash.
+				buf.WriteByte(' ')
+				writeMapEntries(buf, m, indent+1, true)
+				continue
+			}
+			writeYAML(buf, item, indent+1)
+		}
+	default:
+		buf.WriteByte(' ')
+		buf.WriteString(yamlScalar(v))
+		buf.WriteByte('\n')
+	}
+}
+
+func writeMapEntries(buf *bytes.Buffer, m map[string]interface{}, indent int, inline bool) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for i, k := range keys {
+		if !(inline && i == 0) {
+			buf.WriteString(strings.Repeat("  ", indent))
+		}
+		buf.WriteString(yamlKey(k) + ":")
+		writeYAML(buf, m[k], indent+1)
+	}
+}
+
+func yamlKey(k string) string {
+	if plainKey.MatchString(k) && !yamlKeywords[strings.ToLower(k)] {
+		return k
+	}
+	return yamlString(k)
+}
+
+func yamlScalar(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	case json.Number:
+		return v.String()
+	case string:
+		return yamlString(v)
+	}
+	return "null"
+}
+
+func yamlString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
@@ -0,0 +1,75 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package server
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"net/http"
+)
+
+// PeerIdentity is the identity of a client authenticated by a verified TLS certificate.
+type PeerIdentity struct {
+	Subject        pkix.Name
+	DNSNames       []string
+	EmailAddresses []string
+	IPAddresses    []net.IP
+	URIs           []string
+	// SPIFFEID is the first spiffe:// URI SAN, if any.
+	SPIFFEID    string
+	Certificate *x509.Certificate
+}
+
+// CommonName returns the common name of the subject.
+func (p *PeerIdentity) CommonName() string {
+	return p.Subject.CommonName
+}
+
+// PeerIdentity returns the identity of the client certificate verified during the TLS
+// handshake, or nil if the client did not present a verified certificate.
+func (c *Context) PeerIdentity() *PeerIdentity {
+	if c.request == nil || c.request.TLS == nil {
+		return nil
+	}
+
+	chains := c.request.TLS.VerifiedChains
+	if len(chains) == 0 || len(chains[0]) == 0 {
+		return nil
+	}
+
+	cert := chains[0][0]
+	identity := &PeerIdentity{
+		Subject:        cert.Subject,
+		DNSNames:       cert.DNSNames,
+		EmailAddresses: cert.EmailAddresses,
+		IPAddresses:    cert.IPAddresses,
+		Certificate:    cert,
+	}
+
+	for _, uri := range cert.URIs {
+		identity.URIs = append(identity.URIs, uri.String())
+		if identity.SPIFFEID == "" && uri.Scheme == "spiffe" {
+			identity.SPIFFEID = uri.String()
+		}
+	}
+
+	return identity
+}
+
+// ClientCertificateFilter returns a filter which requires a verified client certificate,
+// and, if authorize is not nil, that it accepts the identity. Rejected requests get a 403.
+func ClientCertificateFilter(authorize func(*PeerIdentity) bool) FilterFunc {
+	return func(c *Context) bool {
+		identity := c.PeerIdentity()
+		if identity == nil || (authorize != nil && !authorize(identity)) {
+			http.Error(c.Response(), http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return false
+		}
+
+		return true
+	}
+}
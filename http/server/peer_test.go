@@ -0,0 +1,199 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testCA signs the client certificates of the mutual TLS tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	file string
+}
+
+func newTestCA(t *testing.T, dir string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "apix test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := filepath.Join(dir, "ca.crt")
+	if err = ioutil.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return &testCA{cert: cert, key: key, file: file}
+}
+
+// client returns a client certificate of tmpl signed by the CA.
+func (ca *testCA) client(t *testing.T, tmpl *x509.Certificate) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl.SerialNumber = big.NewInt(time.Now().UnixNano())
+	tmpl.NotBefore = time.Now().Add(-time.Hour)
+	tmpl.NotAfter = time.Now().Add(time.Hour)
+	tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestPeerIdentity(t *testing.T) {
+	dir, err := ioutil.TempDir("", "apix-peer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca := newTestCA(t, dir)
+	pair := writeCertificate(t, dir, "server", "localhost")
+
+	rt := NewRouter()
+	rt.Get("/peer", func(c *Context) error {
+		identity := *c.PeerIdentity()
+		if identity.Certificate == nil {
+			return c.WriteHeader(http.StatusInternalServerError)
+		}
+		identity.Certificate = nil
+		return c.ServeJSON(&identity)
+	}, ClientCertificateFilter(nil))
+	rt.Get("/billing", func(c *Context) error {
+		return c.WriteHeader(http.StatusOK)
+	}, ClientCertificateFilter(func(p *PeerIdentity) bool {
+		return p.SPIFFEID == "spiffe://example.org/billing"
+	}))
+
+	ep := NewEntrypoint(&Configuration{Address: "127.0.0.1:0"}, &TLSConfiguration{
+		Cert:       pair.Cert,
+		Key:        pair.Key,
+		ClientCAs:  []string{ca.file},
+		ClientAuth: tls.VerifyClientCertIfGiven,
+	})
+	if err = ep.Start(rt); err != nil {
+		t.Fatal(err)
+	}
+	defer ep.Stop()
+
+	base := "https://" + ep.listeners[0].listener.Addr().String()
+	client := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: true,
+			Certificates:       certs,
+		}}}
+	}
+
+	spiffe, _ := url.Parse("spiffe://example.org/orders")
+	site, _ := url.Parse("https://orders.example.org/")
+	cert := ca.client(t, &x509.Certificate{
+		Subject:  pkix.Name{CommonName: "orders", Organization: []string{"apix"}},
+		DNSNames: []string{"orders.internal"},
+		URIs:     []*url.URL{site, spiffe},
+	})
+
+	resp, err := client(cert).Get(base + "/peer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var identity PeerIdentity
+	err = json.NewDecoder(resp.Body).Decode(&identity)
+	resp.Body.Close()
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected response %d %v", resp.StatusCode, err)
+	}
+
+	if identity.Subject.CommonName != "orders" || !reflect.DeepEqual(identity.Subject.Organization, []string{"apix"}) {
+		t.Fatalf("unexpected subject %+v", identity.Subject)
+	}
+	if !reflect.DeepEqual(identity.DNSNames, []string{"orders.internal"}) {
+		t.Fatalf("unexpected DNS names %v", identity.DNSNames)
+	}
+	if !reflect.DeepEqual(identity.URIs, []string{"https://orders.example.org/", "spiffe://example.org/orders"}) || identity.SPIFFEID != "spiffe://example.org/orders" {
+		t.Fatalf("unexpected URIs %v, SPIFFE ID %q", identity.URIs, identity.SPIFFEID)
+	}
+
+	for _, c := range []struct {
+		path   string
+		client *http.Client
+		status int
+	}{
+		{"/peer", client(), http.StatusForbidden},
+		{"/billing", client(cert), http.StatusForbidden},
+	} {
+		resp, err := c.client.Get(base + c.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != c.status {
+			t.Fatalf("%s: unexpected status %d", c.path, resp.StatusCode)
+		}
+	}
+}
+
+func TestClientAuthRequiresCAs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "apix-peer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	pair := writeCertificate(t, dir, "server", "localhost")
+
+	for _, auth := range []tls.ClientAuthType{tls.VerifyClientCertIfGiven, tls.RequireAndVerifyClientCert} {
+		if _, _, err := createTLSConfig(&TLSConfiguration{Cert: pair.Cert, Key: pair.Key, ClientAuth: auth}); err != errNoClientCA {
+			t.Fatalf("%v without client CAs: %v", auth, err)
+		}
+	}
+
+	ca := newTestCA(t, dir)
+	config, _, err := createTLSConfig(&TLSConfiguration{Cert: pair.Cert, Key: pair.Key, ClientCAs: []string{ca.file}, ClientAuth: tls.RequireAndVerifyClientCert})
+	if err != nil || config.ClientCAs == nil || config.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Fatalf("unexpected configuration %+v %v", config, err)
+	}
+}
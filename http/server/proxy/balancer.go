@@ -0,0 +1,211 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package proxy
+
+import (
+	"errors"
+	"hash/crc32"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Balance is an algorithm to select the upstream of a request.
+type Balance string
+
+// Balancing algorithms.
+const (
+	// RoundRobin selects the available upstreams in turn.
+	RoundRobin Balance = "round_robin"
+	// LeastConnections selects the available upstream with the fewest requests in flight.
+	LeastConnections Balance = "least_connections"
+	// ConsistentHash selects the same upstream for the same key while it is available, and
+	// moves only the keys of an upstream which becomes unavailable.
+	ConsistentHash Balance = "consistent_hash"
+)
+
+// replicas is the number of virtual nodes of an upstream on the hash ring.
+const replicas = 160
+
+var errBalance = errors.New("unknown balancing algorithm")
+
+// upstream is a backend and its state.
+type upstream struct {
+	url   *url.URL
+	proxy *httputil.ReverseProxy
+
+	active  int64
+	healthy int32
+
+	mu        sync.Mutex
+	fails     int
+	downUntil time.Time
+}
+
+func newUpstream(target *url.URL) *upstream {
+	return &upstream{url: target, healthy: 1}
+}
+
+func (u *upstream) acquire() {
+	atomic.AddInt64(&u.active, 1)
+}
+
+func (u *upstream) release() {
+	atomic.AddInt64(&u.active, -1)
+}
+
+func (u *upstream) connections() int64 {
+	return atomic.LoadInt64(&u.active)
+}
+
+// available reports whether the upstream passes the active check and is not marked down
+// by failed requests.
+func (u *upstream) available() bool {
+	if atomic.LoadInt32(&u.healthy) == 0 {
+		return false
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return !time.Now().Before(u.downUntil)
+}
+
+func (u *upstream) setHealthy(healthy bool) {
+	var v int32
+	if healthy {
+		v = 1
+	}
+	atomic.StoreInt32(&u.healthy, v)
+}
+
+// failed counts a failed request, the upstream is marked down for timeout after maxFails
+// consecutive ones.
+func (u *upstream) failed(maxFails int, timeout time.Duration) {
+	if maxFails < 0 {
+		return
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.fails++
+	if u.fails >= maxFails {
+		u.fails = 0
+		u.downUntil = time.Now().Add(timeout)
+	}
+}
+
+// succeeded resets the consecutive failures.
+func (u *upstream) succeeded() {
+	u.mu.Lock()
+	u.fails = 0
+	u.mu.Unlock()
+}
+
+// balancer selects an available upstream which was not tried yet, or nil.
+type balancer interface {
+	pick(key string, tried map[*upstream]bool) *upstream
+}
+
+func newBalancer(b Balance, upstreams []*upstream) (balancer, error) {
+	switch b {
+	case RoundRobin:
+		return &roundRobin{upstreams: upstreams}, nil
+	case LeastConnections:
+		return &leastConnections{upstreams: upstreams}, nil
+	case ConsistentHash:
+		return newHashRing(upstreams), nil
+	}
+	return nil, errBalance
+}
+
+func usable(u *upstream, tried map[*upstream]bool) bool {
+	return !tried[u] && u.available()
+}
+
+type roundRobin struct {
+	upstreams []*upstream
+	next      uint32
+}
+
+func (rr *roundRobin) pick(_ string, tried map[*upstream]bool) *upstream {
+	n := uint32(len(rr.upstreams))
+	start := atomic.AddUint32(&rr.next, 1) - 1
+
+	for i := uint32(0); i < n; i++ {
+		if u := rr.upstreams[(start+i)%n]; usable(u, tried) {
+			return u
+		}
+	}
+	return nil
+}
+
+type leastConnections struct {
+	upstreams []*upstream
+	next      uint32
+}
+
+// pick starts from a rotating offset, so that ties are spread.
+func (lc *leastConnections) pick(_ string, tried map[*upstream]bool) *upstream {
+	var (
+		best  *upstream
+		least int64
+		n     = uint32(len(lc.upstreams))
+		start = atomic.AddUint32(&lc.next, 1) - 1
+	)
+
+	for i := uint32(0); i < n; i++ {
+		u := lc.upstreams[(start+i)%n]
+		if !usable(u, tried) {
+			continue
+		}
+
+		if c := u.connections(); best == nil || c < least {
+			best, least = u, c
+		}
+	}
+	return best
+}
+
+type hashRing struct {
+	hashes []uint32
+	nodes  map[uint32]*upstream
+}
+
+func newHashRing(upstreams []*upstream) *hashRing {
+	ring := &hashRing{nodes: make(map[uint32]*upstream, len(upstreams)*replicas)}
+
+	for _, u := range upstreams {
+		for i := 0; i < replicas; i++ {
+			h := crc32.ChecksumIEEE([]byte(u.url.String() + "#" + strconv.Itoa(i)))
+			if _, ok := ring.nodes[h]; ok {
+				continue
+			}
+			ring.nodes[h] = u
+			ring.hashes = append(ring.hashes, h)
+		}
+	}
+
+	sort.Slice(ring.hashes, func(i, j int) bool { return ring.hashes[i] < ring.hashes[j] })
+	return ring
+}
+
+// pick walks the ring clockwise from the hash of key to the first usable upstream.
+func (ring *hashRing) pick(key string, tried map[*upstream]bool) *upstream {
+	h := crc32.ChecksumIEEE([]byte(key))
+	start := sort.Search(len(ring.hashes), func(i int) bool { return ring.hashes[i] >= h })
+
+	for i := 0; i < len(ring.hashes); i++ {
+		if u := ring.nodes[ring.hashes[(start+i)%len(ring.hashes)]]; usable(u, tried) {
+			return u
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package proxy
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultCheckInterval = 10 * time.Second
+	defaultCheckTimeout  = 2 * time.Second
+)
+
+// HealthCheck probes every upstream periodically with a GET request, an upstream
+// responding with a status other than 2xx or 3xx is not selected until it recovers.
+type HealthCheck struct {
+	// Path is requested on the upstream, such as "/healthz".
+	Path string
+	// Interval between the probes, 10 seconds if it is zero.
+	Interval time.Duration
+	// Timeout of a probe, 2 seconds if it is zero.
+	Timeout time.Duration
+}
+
+func (p *Proxy) checkHealth(hc *HealthCheck) {
+	interval := hc.Interval
+	if interval <= 0 {
+		interval = defaultCheckInterval
+	}
+	timeout := hc.Timeout
+	if timeout <= 0 {
+		timeout = defaultCheckTimeout
+	}
+
+	client := &http.Client{
+		Transport: p.opt.Transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for _, u := range p.upstreams {
+			u.setHealthy(p.probe(client, u, hc.Path, timeout))
+		}
+
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Proxy) probe(client *http.Client, u *upstream, path string, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	target := *u.url
+	target.Path = joinPath(target.Path, path)
+	target.RawQuery = ""
+
+	req, err := http.NewRequest(http.MethodGet, target.String(), nil)
+	if err != nil {
+		return false
+	}
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return false
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+
+	return resp.StatusCode >= 200 && resp.StatusCode < 400
+}
@@ -0,0 +1,394 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+// Package proxy forwards requests to a pool of upstreams, balanced by round-robin, least
+// connections or consistent hashing, with active and passive health checks and retries:
+//
+//	p, err := proxy.New(proxy.Options{
+//		Upstreams:   []string{"http://10.0.0.1:8080", "http://10.0.0.2:8080"},
+//		Balance:     proxy.LeastConnections,
+//		StripPrefix: "/legacy",
+//		HealthCheck: &proxy.HealthCheck{Path: "/healthz"},
+//	})
+//	rt.Any("/legacy/{path:.*}", p.Handle, authFilter)
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TechCatsLab/apix/http/server"
+)
+
+const (
+	defaultMaxFails      = 3
+	defaultFailTimeout   = 10 * time.Second
+	defaultFlushInterval = 100 * time.Millisecond
+
+	// maxRetryBodyBytes is the largest request body buffered to be sent again.
+	maxRetryBodyBytes = 1 << 20
+)
+
+var (
+	errNoUpstream  = errors.New("proxy requires at least one upstream")
+	errUpstreamURL = errors.New("upstream must be an absolute http or https URL")
+	errRetryStatus = errors.New("upstream responded with a retryable status")
+	errNoneHealthy = errors.New("no healthy upstream")
+)
+
+// idempotentMethods are retried on another upstream.
+var idempotentMethods = map[string]bool{
+	server.GET:     true,
+	server.HEAD:    true,
+	server.OPTIONS: true,
+	server.PUT:     true,
+	server.DELETE:  true,
+	"TRACE":        true,
+}
+
+// Options configures a Proxy.
+type Options struct {
+	// Upstreams are the base URLs of the backends, such as "http://10.0.0.1:8080/api".
+	Upstreams []string
+	// Balance selects the upstream of a request, RoundRobin if it is empty.
+	Balance Balance
+	// HashHeader and HashCookie are the keys of ConsistentHash, the client IP without them.
+	HashHeader string
+	HashCookie string
+
+	// HealthCheck probes the upstreams periodically if it is not nil.
+	HealthCheck *HealthCheck
+	// MaxFails consecutive failures of requests mark an upstream down for FailTimeout, 3
+	// and 10 seconds if they are zero. A negative MaxFails disables passive checks.
+	MaxFails    int
+	FailTimeout time.Duration
+
+	// Retries is the number of other upstreams an idempotent request is sent to after a
+	// connection failure or a 502, 503 or 504 response.
+	Retries int
+
+	// StripPrefix is removed from the request path before it is joined to the upstream path.
+	StripPrefix string
+	// PreserveHost sends the Host of the client instead of the one of the upstream.
+	PreserveHost bool
+	// Headers rewrites the request and response headers.
+	Headers HeaderRewrite
+
+	// Transport sends the requests, http.DefaultTransport if it is nil.
+	Transport http.RoundTripper
+	// FlushInterval is the interval to flush the response while copying it, 100ms if it
+	// is zero, a negative value flushes after every write. Server-sent events are always
+	// flushed immediately.
+	FlushInterval time.Duration
+}
+
+// HeaderRewrite sets and removes headers of the requests and responses.
+type HeaderRewrite struct {
+	SetRequest     map[string]string
+	RemoveRequest  []string
+	SetResponse    map[string]string
+	RemoveResponse []string
+}
+
+// Proxy forwards requests to its upstreams.
+type Proxy struct {
+	opt       Options
+	upstreams []*upstream
+	balancer  balancer
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+type attemptKey struct{}
+
+// attempt is the state of a try to forward a request, shared with the ReverseProxy hooks
+// through the request context.
+type attempt struct {
+	retry bool
+	err   error
+}
+
+// New creates a Proxy and starts its active health checks.
+func New(opt Options) (*Proxy, error) {
+	if len(opt.Upstreams) == 0 {
+		return nil, errNoUpstream
+	}
+
+	if opt.Balance == "" {
+		opt.Balance = RoundRobin
+	}
+	if opt.MaxFails == 0 {
+		opt.MaxFails = defaultMaxFails
+	}
+	if opt.FailTimeout <= 0 {
+		opt.FailTimeout = defaultFailTimeout
+	}
+	if opt.FlushInterval == 0 {
+		opt.FlushInterval = defaultFlushInterval
+	}
+	if opt.Transport == nil {
+		opt.Transport = http.DefaultTransport
+	}
+
+	p := &Proxy{
+		opt:  opt,
+		stop: make(chan struct{}),
+	}
+
+	for _, raw := range opt.Upstreams {
+		target, err := url.Parse(raw)
+		if err != nil {
+			return nil, err
+		}
+		if (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			return nil, errUpstreamURL
+		}
+
+		u := newUpstream(target)
+		u.proxy = p.reverseProxy(target)
+		p.upstreams = append(p.upstreams, u)
+	}
+
+	b, err := newBalancer(opt.Balance, p.upstreams)
+	if err != nil {
+		return nil, err
+	}
+	p.balancer = b
+
+	if opt.HealthCheck != nil {
+		go p.checkHealth(opt.HealthCheck)
+	}
+
+	return p, nil
+}
+
+// Close stops the active health checks.
+func (p *Proxy) Close() {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+	})
+}
+
+// Handle forwards the request of c, it is a server.HandlerFunc for Router routes.
+func (p *Proxy) Handle(c *server.Context) error {
+	p.serve(c.Response(), c.Request(), c.ClientIP(), c.Scheme(), c.Host())
+	return nil
+}
+
+// ServeHTTP forwards r, for serving the proxy without a Router.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c := server.NewContext(w, r)
+	p.Handle(c)
+}
+
+func (p *Proxy) serve(w http.ResponseWriter, r *http.Request, clientIP, scheme, host string) {
+	out := r.WithContext(r.Context())
+	out.Header = cloneHeader(r.Header)
+	out.Header.Set(server.HeaderXForwardedProto, scheme)
+	out.Header.Set(server.HeaderXForwardedHost, host)
+
+	for _, key := range p.opt.Headers.RemoveRequest {
+		out.Header.Del(key)
+	}
+	for key, value := range p.opt.Headers.SetRequest {
+		out.Header.Set(key, value)
+	}
+
+	if p.opt.StripPrefix != "" {
+		out.URL = cloneURL(r.URL)
+		out.URL.Path = strings.TrimPrefix(out.URL.Path, p.opt.StripPrefix)
+		out.URL.RawPath = ""
+		if !strings.HasPrefix(out.URL.Path, "/") {
+			out.URL.Path = "/" + out.URL.Path
+		}
+	}
+
+	retries := p.opt.Retries
+	body, replayable := p.replayableBody(out)
+	if !replayable {
+		retries = 0
+	}
+
+	key := p.hashKey(r, clientIP)
+	tried := make(map[*upstream]bool)
+
+	for i := 0; ; i++ {
+		u := p.balancer.pick(key, tried)
+		if u == nil {
+			http.Error(w, errNoneHealthy.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		tried[u] = true
+
+		a := &attempt{retry: i < retries && len(tried) < len(p.upstreams)}
+		req := out.WithContext(context.WithValue(out.Context(), attemptKey{}, a))
+		if body != nil {
+			req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+
+		u.acquire()
+		u.proxy.ServeHTTP(w, req)
+		u.release()
+
+		if a.err == nil {
+			u.succeeded()
+			return
+		}
+
+		// A client which went away is not a failure of the upstream.
+		if r.Context().Err() != nil {
+			return
+		}
+
+		u.failed(p.opt.MaxFails, p.opt.FailTimeout)
+		if !a.retry {
+			return
+		}
+		server.Logf(server.LevelWarn, "Proxy upstream %s retrying: %v", u.url.Host, a.err)
+	}
+}
+
+// replayableBody buffers the body of r if the request can be retried.
+func (p *Proxy) replayableBody(r *http.Request) ([]byte, bool) {
+	if p.opt.Retries <= 0 || !idempotentMethods[r.Method] {
+		return nil, false
+	}
+
+	if r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0 {
+		return nil, true
+	}
+
+	if r.ContentLength < 0 || r.ContentLength > maxRetryBodyBytes {
+		return nil, false
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxRetryBodyBytes))
+	if err != nil {
+		return nil, false
+	}
+	r.Body.Close()
+	return body, true
+}
+
+func (p *Proxy) hashKey(r *http.Request, clientIP string) string {
+	if p.opt.Balance != ConsistentHash {
+		return ""
+	}
+
+	if p.opt.HashHeader != "" {
+		if v := r.Header.Get(p.opt.HashHeader); v != "" {
+			return v
+		}
+	}
+	if p.opt.HashCookie != "" {
+		if c, err := r.Cookie(p.opt.HashCookie); err == nil && c.Value != "" {
+			return c.Value
+		}
+	}
+	return clientIP
+}
+
+// reverseProxy creates the ReverseProxy of an upstream. Its ErrorHandler records the
+// failure for a retry instead of responding, unless it is the last attempt.
+func (p *Proxy) reverseProxy(target *url.URL) *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		Director: func(r *http.Request) {
+			r.URL.Scheme = target.Scheme
+			r.URL.Host = target.Host
+			r.URL.Path = joinPath(target.Path, r.URL.Path)
+			r.URL.RawPath = ""
+
+			switch {
+			case target.RawQuery == "":
+			case r.URL.RawQuery == "":
+				r.URL.RawQuery = target.RawQuery
+			default:
+				r.URL.RawQuery = target.RawQuery + "&" + r.URL.RawQuery
+			}
+
+			if !p.opt.PreserveHost {
+				r.Host = target.Host
+			}
+
+			// Keep net/http from adding its own User-Agent.
+			if _, ok := r.Header["User-Agent"]; !ok {
+				r.Header.Set("User-Agent", "")
+			}
+		},
+		Transport:     p.opt.Transport,
+		FlushInterval: p.opt.FlushInterval,
+		ModifyResponse: func(resp *http.Response) error {
+			switch resp.StatusCode {
+			case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+				if a := attemptOf(resp.Request); a != nil && a.retry {
+					return errRetryStatus
+				}
+			}
+
+			for _, key := range p.opt.Headers.RemoveResponse {
+				resp.Header.Del(key)
+			}
+			for key, value := range p.opt.Headers.SetResponse {
+				resp.Header.Set(key, value)
+			}
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			if a := attemptOf(r); a != nil {
+				a.err = err
+				if a.retry && r.Context().Err() == nil {
+					return
+				}
+			}
+
+			if r.Context().Err() == nil {
+				server.Logf(server.LevelWarn, "Proxy upstream %s: %v", target.Host, err)
+			}
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+}
+
+func attemptOf(r *http.Request) *attempt {
+	a, _ := r.Context().Value(attemptKey{}).(*attempt)
+	return a
+}
+
+func joinPath(base, path string) string {
+	switch {
+	case base == "" || base == "/":
+		return path
+	case path == "" || path == "/":
+		return base
+	}
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+func cloneHeader(h http.Header) http.Header {
+	c := make(http.Header, len(h))
+	for key, values := range h {
+		c[key] = append([]string(nil), values...)
+	}
+	return c
+}
+
+func cloneURL(u *url.URL) *url.URL {
+	c := *u
+	if u.User != nil {
+		user := *u.User
+		c.User = &user
+	}
+	return &c
+}
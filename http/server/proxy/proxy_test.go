@@ -0,0 +1,352 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package proxy
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TechCatsLab/apix/http/server"
+	"github.com/TechCatsLab/apix/http/server/servertest"
+)
+
+func named(name string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Upstream", name)
+		io.WriteString(w, name+" "+r.URL.Path)
+	}))
+}
+
+func deadURL() string {
+	s := httptest.NewServer(http.NotFoundHandler())
+	s.Close()
+	return s.URL
+}
+
+func newProxy(t *testing.T, opt Options) *Proxy {
+	p, err := New(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestRoundRobin(t *testing.T) {
+	a, b := named("a"), named("b")
+	defer a.Close()
+	defer b.Close()
+
+	s := servertest.New(newProxy(t, Options{Upstreams: []string{a.URL, b.URL}}))
+
+	counts := make(map[string]int)
+	for i := 0; i < 4; i++ {
+		counts[s.Get("/").Do(t).Status(http.StatusOK).Recorder.Header().Get("X-Upstream")]++
+	}
+	if counts["a"] != 2 || counts["b"] != 2 {
+		t.Fatalf("unexpected distribution %v", counts)
+	}
+}
+
+func TestRetryAndPassiveCheck(t *testing.T) {
+	a := named("a")
+	defer a.Close()
+
+	p := newProxy(t, Options{
+		Upstreams: []string{deadURL(), a.URL},
+		Retries:   1,
+		MaxFails:  1,
+	})
+	s := servertest.New(p)
+
+	for i := 0; i < 3; i++ {
+		s.Get("/").Do(t).Status(http.StatusOK).Header("X-Upstream", "a")
+	}
+
+	if p.upstreams[0].available() {
+		t.Fatal("the dead upstream was not marked down")
+	}
+	if !p.upstreams[1].available() {
+		t.Fatal("the live upstream was marked down")
+	}
+}
+
+func TestNoRetryOfPost(t *testing.T) {
+	a := named("a")
+	defer a.Close()
+
+	p := newProxy(t, Options{
+		Upstreams: []string{deadURL(), a.URL},
+		Retries:   1,
+		MaxFails:  -1,
+	})
+	s := servertest.New(p)
+
+	codes := make(map[int]int)
+	for i := 0; i < 2; i++ {
+		codes[s.Post("/").Body(strings.NewReader("x"), "text/plain").Do(t).Code()]++
+	}
+	if codes[http.StatusBadGateway] != 1 || codes[http.StatusOK] != 1 {
+		t.Fatalf("unexpected statuses %v", codes)
+	}
+}
+
+func TestRetryStatus(t *testing.T) {
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+	a := named("a")
+	defer a.Close()
+
+	s := servertest.New(newProxy(t, Options{
+		Upstreams: []string{unavailable.URL, a.URL},
+		Retries:   1,
+		MaxFails:  -1,
+	}))
+
+	for i := 0; i < 2; i++ {
+		s.Get("/").Do(t).Status(http.StatusOK).Header("X-Upstream", "a")
+	}
+}
+
+func TestNoneAvailable(t *testing.T) {
+	s := servertest.New(newProxy(t, Options{Upstreams: []string{deadURL()}, MaxFails: 1}))
+
+	s.Get("/").Do(t).Status(http.StatusBadGateway)
+	s.Get("/").Do(t).Status(http.StatusServiceUnavailable)
+}
+
+func TestConsistentHash(t *testing.T) {
+	var upstreams []string
+	for _, name := range []string{"a", "b", "c"} {
+		s := named(name)
+		defer s.Close()
+		upstreams = append(upstreams, s.URL)
+	}
+
+	s := servertest.New(newProxy(t, Options{
+		Upstreams:  upstreams,
+		Balance:    ConsistentHash,
+		HashHeader: "X-User",
+		HashCookie: "session",
+	}))
+
+	seen := make(map[string]bool)
+	for _, user := range []string{"1", "2", "3", "4", "5", "6", "7", "8"} {
+		first := s.Get("/").Header("X-User", user).Do(t).Recorder.Header().Get("X-Upstream")
+		for i := 0; i < 3; i++ {
+			s.Get("/").Header("X-User", user).Do(t).Header("X-Upstream", first)
+		}
+		seen[first] = true
+	}
+	if len(seen) < 2 {
+		t.Fatalf("the keys were not spread: %v", seen)
+	}
+
+	first := s.Get("/").Cookie(&http.Cookie{Name: "session", Value: "s1"}).Do(t).Recorder.Header().Get("X-Upstream")
+	s.Get("/").Cookie(&http.Cookie{Name: "session", Value: "s1"}).Do(t).Header("X-Upstream", first)
+}
+
+func TestLeastConnections(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		io.WriteString(w, "slow")
+	}))
+	defer slow.Close()
+	defer close(release)
+	a := named("a")
+	defer a.Close()
+
+	p := newProxy(t, Options{Upstreams: []string{slow.URL, a.URL}, Balance: LeastConnections})
+	p.upstreams[0].acquire()
+	defer p.upstreams[0].release()
+
+	s := servertest.New(p)
+	for i := 0; i < 3; i++ {
+		s.Get("/").Do(t).Header("X-Upstream", "a")
+	}
+}
+
+func TestRouterRewrite(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "upstream")
+		w.Header().Set("X-Seen", r.Header.Get("X-Gateway")+"|"+r.Header.Get("Cookie")+"|"+r.Header.Get(server.HeaderXForwardedHost))
+		io.WriteString(w, r.URL.Path+"?"+r.URL.RawQuery)
+	}))
+	defer upstream.Close()
+
+	p := newProxy(t, Options{
+		Upstreams:   []string{upstream.URL + "/v2?key=1"},
+		StripPrefix: "/api",
+		Headers: HeaderRewrite{
+			SetRequest:     map[string]string{"X-Gateway": "apix"},
+			RemoveRequest:  []string{"Cookie"},
+			SetResponse:    map[string]string{"X-Proxy": "apix"},
+			RemoveResponse: []string{"Server"},
+		},
+	})
+
+	blocked := func(c *server.Context) bool {
+		if c.GetHeader("X-Block") != "" {
+			c.WriteHeader(http.StatusForbidden)
+			return false
+		}
+		return true
+	}
+
+	rt := server.NewRouter()
+	rt.Any("/api/{path:.*}", p.Handle, blocked)
+	s := servertest.New(rt)
+
+	s.Delete("/api/users/1").Query("q", "x").Header("Cookie", "a=b").Do(t).
+		Status(http.StatusOK).
+		Header("X-Proxy", "apix").
+		Header("Server", "").
+		Header("X-Seen", "apix||example.com").
+		BodyContains("/v2/users/1?key=1&q=x")
+
+	s.Get("/api/users").Header("X-Block", "1").Do(t).Status(http.StatusForbidden)
+}
+
+func TestActiveHealthCheck(t *testing.T) {
+	sick := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("X-Upstream", "sick")
+	}))
+	defer sick.Close()
+	a := named("a")
+	defer a.Close()
+
+	p := newProxy(t, Options{
+		Upstreams:   []string{sick.URL, a.URL},
+		HealthCheck: &HealthCheck{Path: "/healthz", Interval: 10 * time.Millisecond},
+	})
+	defer p.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for p.upstreams[0].available() {
+		if time.Now().After(deadline) {
+			t.Fatal("the sick upstream is still available")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	s := servertest.New(p)
+	for i := 0; i < 3; i++ {
+		s.Get("/").Do(t).Header("X-Upstream", "a")
+	}
+}
+
+func TestStreaming(t *testing.T) {
+	done := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(server.HeaderContentType, "text/event-stream")
+		io.WriteString(w, "data: hello\n\n")
+		w.(http.Flusher).Flush()
+		<-done
+	}))
+	defer upstream.Close()
+	defer close(done)
+
+	p := newProxy(t, Options{Upstreams: []string{upstream.URL}, FlushInterval: time.Hour})
+	front := httptest.NewServer(p)
+	defer front.Close()
+
+	resp, err := http.Get(front.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	line := make(chan string, 1)
+	go func() {
+		l, _ := bufio.NewReader(resp.Body).ReadString('\n')
+		line <- l
+	}()
+
+	select {
+	case l := <-line:
+		if l != "data: hello\n" {
+			t.Fatalf("unexpected event %q", l)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("the event was not flushed")
+	}
+}
+
+func TestWebSocketUpgrade(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(server.HeaderUpgrade) != "websocket" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n")
+		rw.Flush()
+
+		line, _ := rw.ReadString('\n')
+		rw.WriteString("echo " + line)
+		rw.Flush()
+	}))
+	defer upstream.Close()
+
+	front := httptest.NewServer(newProxy(t, Options{Upstreams: []string{upstream.URL}}))
+	defer front.Close()
+
+	req, _ := http.NewRequest(server.GET, front.URL+"/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set(server.HeaderUpgrade, "websocket")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		b, _ := ioutil.ReadAll(resp.Body)
+		t.Fatalf("unexpected status %d: %s", resp.StatusCode, b)
+	}
+
+	conn, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		t.Fatal("the upgraded body is not writable")
+	}
+	io.WriteString(conn, "ping\n")
+
+	line, _ := bufio.NewReader(conn).ReadString('\n')
+	if line != "echo ping\n" {
+		t.Fatalf("unexpected message %q", line)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, opt := range []Options{
+		{},
+		{Upstreams: []string{"10.0.0.1:80"}},
+		{Upstreams: []string{"http://10.0.0.1"}, Balance: "random"},
+	} {
+		if _, err := New(opt); err == nil {
+			t.Fatalf("%+v was accepted", opt)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultProxyHeaderTimeout = 5 * time.Second
+
+	// The longest v1 header is 107 bytes including CRLF.
+	proxyV1MaxLength = 107
+)
+
+var (
+	proxyV1Prefix    = []byte("PROXY ")
+	proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+	errProxyHeader   = errors.New("invalid PROXY protocol header")
+	errProxyRequired = errors.New("PROXY protocol header is required")
+)
+
+// ProxyProtocolConfiguration enables the PROXY protocol v1 and v2 on a listener. The
+// client address of the header replaces the address of the load balancer.
+type ProxyProtocolConfiguration struct {
+	// TrustedCIDRs are the networks allowed to send a header, such as "10.0.0.0/8".
+	// The header of other peers is not parsed and is handed to the server as is.
+	TrustedCIDRs []string
+	// HeaderTimeout is the maximum time to read the header, 5 seconds if it is zero.
+	HeaderTimeout time.Duration
+	// Required rejects the trusted connections without a header.
+	Required bool
+}
+
+// proxyListener parses the PROXY protocol header of the accepted connections.
+type proxyListener struct {
+	net.Listener
+	trusted  []*net.IPNet
+	timeout  time.Duration
+	required bool
+}
+
+func newProxyListener(l net.Listener, conf *ProxyProtocolConfiguration) (net.Listener, error) {
+	trusted, err := parseCIDRs(conf.TrustedCIDRs)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := conf.HeaderTimeout
+	if timeout <= 0 {
+		timeout = defaultProxyHeaderTimeout
+	}
+
+	return &proxyListener{
+		Listener: l,
+		trusted:  trusted,
+		timeout:  timeout,
+		required: conf.Required,
+	}, nil
+}
+
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			if strings.Contains(cidr, ":") {
+				cidr += "/128"
+			} else {
+				cidr += "/32"
+			}
+		}
+
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Accept returns the connection without reading, the header is parsed by the first
+// Read or RemoteAddr in the goroutine serving the connection.
+func (l *proxyListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	if ip := addrIP(conn.RemoteAddr()); ip == nil || !containsIP(l.trusted, ip) {
+		return conn, nil
+	}
+
+	return &proxyConn{
+		Conn:     conn,
+		reader:   bufio.NewReader(conn),
+		timeout:  l.timeout,
+		required: l.required,
+	}, nil
+}
+
+func addrIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		return a.IP
+	case *net.UDPAddr:
+		return a.IP
+	}
+
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return nil
+	}
+	return net.ParseIP(host)
+}
+
+// proxyConn is a connection from a trusted proxy.
+type proxyConn struct {
+	net.Conn
+	reader   *bufio.Reader
+	timeout  time.Duration
+	required bool
+
+	once       sync.Once
+	err        error
+	remoteAddr net.Addr
+	localAddr  net.Addr
+}
+
+func (c *proxyConn) Read(b []byte) (int, error) {
+	c.once.Do(c.readHeader)
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.reader.Read(b)
+}
+
+func (c *proxyConn) RemoteAddr() net.Addr {
+	c.once.Do(c.readHeader)
+	if c.remoteAddr != nil {
+		return c.remoteAddr
+	}
+	return c.Conn.RemoteAddr()
+}
+
+func (c *proxyConn) LocalAddr() net.Addr {
+	c.once.Do(c.readHeader)
+	if c.localAddr != nil {
+		return c.localAddr
+	}
+	return c.Conn.LocalAddr()
+}
+
+func (c *proxyConn) readHeader() {
+	c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+	defer c.Conn.SetReadDeadline(time.Time{})
+
+	c.err = c.parseHeader()
+	if c.err != nil {
+		c.Conn.Close()
+	}
+}
+
+func (c *proxyConn) parseHeader() error {
+	// Peek the shortest prefix telling the versions apart.
+	prefix, err := c.reader.Peek(len(proxyV1Prefix))
+	if err != nil {
+		if c.required {
+			return errProxyRequired
+		}
+		return nil
+	}
+
+	if bytes.Equal(prefix, proxyV1Prefix) {
+		return c.parseV1()
+	}
+
+	if prefix, err = c.reader.Peek(len(proxyV2Signature)); err == nil && bytes.Equal(prefix, proxyV2Signature) {
+		return c.parseV2()
+	}
+
+	if c.required {
+		return errProxyRequired
+	}
+	return nil
+}
+
+// parseV1 parses "PROXY TCP4 192.0.2.1 192.0.2.2 56324 443\r\n".
+func (c *proxyConn) parseV1() error {
+	var line []byte
+	for {
+		b, err := c.reader.ReadByte()
+		if err != nil {
+			return err
+		}
+		line = append(line, b)
+
+		if b == '\n' {
+			break
+		}
+		if len(line) >= proxyV1MaxLength {
+			return errProxyHeader
+		}
+	}
+
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return errProxyHeader
+	}
+
+	fields := strings.Fields(string(line[:len(line)-2]))
+	if len(fields) < 2 {
+		return errProxyHeader
+	}
+
+	switch fields[1] {
+	case "UNKNOWN":
+		return nil
+	case "TCP4", "TCP6":
+	default:
+		return errProxyHeader
+	}
+
+	if len(fields) != 6 {
+		return errProxyHeader
+	}
+
+	src, err := parseV1Addr(fields[2], fields[4])
+	if err != nil {
+		return err
+	}
+	dst, err := parseV1Addr(fields[3], fields[5])
+	if err != nil {
+		return err
+	}
+
+	c.remoteAddr, c.localAddr = src, dst
+	return nil
+}
+
+func parseV1Addr(host, port string) (*net.TCPAddr, error) {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil, errProxyHeader
+	}
+
+	p, err := strconv.Atoi(port)
+	if err != nil || p < 0 || p > 65535 {
+		return nil, errProxyHeader
+	}
+
+	return &net.TCPAddr{IP: ip, Port: p}, nil
+}
+
+// parseV2 parses the binary header: the signature, the version and command, the family,
+// the length and the addresses followed by optional TLVs, which are skipped.
+func (c *proxyConn) parseV2() error {
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(c.reader, header); err != nil {
+		return err
+	}
+
+	if header[12]>>4 != 2 {
+		return errProxyHeader
+	}
+	command := header[12] & 0x0f
+	family := header[13]
+	length := int(binary.BigEndian.Uint16(header[14:]))
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return err
+	}
+
+	switch command {
+	case 0x0:
+		// LOCAL: the proxy's own connection, such as a health check.
+		return nil
+	case 0x1:
+	default:
+		return errProxyHeader
+	}
+
+	switch family >> 4 {
+	case 0x1:
+		if len(payload) < 12 {
+			return errProxyHeader
+		}
+		c.remoteAddr = &net.TCPAddr{IP: net.IP(payload[0:4]), Port: int(binary.BigEndian.Uint16(payload[8:]))}
+		c.localAddr = &net.TCPAddr{IP: net.IP(payload[4:8]), Port: int(binary.BigEndian.Uint16(payload[10:]))}
+	case 0x2:
+		if len(payload) < 36 {
+			return errProxyHeader
+		}
+		c.remoteAddr = &net.TCPAddr{IP: net.IP(payload[0:16]), Port: int(binary.BigEndian.Uint16(payload[32:]))}
+		c.localAddr = &net.TCPAddr{IP: net.IP(payload[16:32]), Port: int(binary.BigEndian.Uint16(payload[34:]))}
+	case 0x0, 0x3:
+		// AF_UNSPEC and AF_UNIX carry no usable client address.
+	default:
+		return fmt.Errorf("%v: unknown family %#x", errProxyHeader, family)
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package server
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+)
+
+func startProxyProtocol(t *testing.T, trusted string) (*Entrypoint, string) {
+	ep := NewEntrypoint(&Configuration{
+		Address: "127.0.0.1:0",
+		ProxyProtocol: &ProxyProtocolConfiguration{
+			TrustedCIDRs: []string{trusted},
+		},
+	}, nil)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.RemoteAddr))
+	})
+	if err := ep.Start(handler); err != nil {
+		t.Fatal(err)
+	}
+
+	return ep, ep.listeners[0].listener.Addr().String()
+}
+
+func proxyRequest(t *testing.T, addr string, header []byte) string {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conn.Write(header)
+	fmt.Fprintf(conn, "GET / HTTP/1.1\r\nHost: test\r\nConnection: close\r\n\r\n")
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	return string(body)
+}
+
+func TestProxyProtocol(t *testing.T) {
+	ep, addr := startProxyProtocol(t, "127.0.0.0/8")
+	defer ep.Stop()
+
+	v1 := []byte("PROXY TCP4 192.0.2.1 192.0.2.2 56324 443\r\n")
+	if got := proxyRequest(t, addr, v1); got != "192.0.2.1:56324" {
+		t.Errorf("v1: unexpected remote address %s", got)
+	}
+
+	v2 := append([]byte{}, proxyV2Signature...)
+	v2 = append(v2, 0x21, 0x11, 0, 12)
+	v2 = append(v2, 198, 51, 100, 7, 192, 0, 2, 2)
+	v2 = append(v2, 0, 0, 0, 0)
+	binary.BigEndian.PutUint16(v2[len(v2)-4:], 40000)
+	binary.BigEndian.PutUint16(v2[len(v2)-2:], 443)
+	if got := proxyRequest(t, addr, v2); got != "198.51.100.7:40000" {
+		t.Errorf("v2: unexpected remote address %s", got)
+	}
+
+	if got := proxyRequest(t, addr, nil); got[:len("127.0.0.1:")] != "127.0.0.1:" {
+		t.Errorf("no header: unexpected remote address %s", got)
+	}
+}
+
+func TestProxyProtocolUntrusted(t *testing.T) {
+	ep, addr := startProxyProtocol(t, "10.0.0.0/8")
+	defer ep.Stop()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// The header of an untrusted peer is not parsed, so the request is malformed.
+	fmt.Fprintf(conn, "PROXY TCP4 192.0.2.1 192.0.2.2 56324 443\r\nGET / HTTP/1.1\r\nHost: test\r\n\r\n")
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unexpected status %d", resp.StatusCode)
+	}
+}
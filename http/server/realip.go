@@ -0,0 +1,255 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package server
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Forwarding headers.
+const (
+	HeaderForwarded       = "Forwarded"
+	HeaderXForwardedFor   = "X-Forwarded-For"
+	HeaderXForwardedProto = "X-Forwarded-Proto"
+	HeaderXForwardedHost  = "X-Forwarded-Host"
+	HeaderXRealIP         = "X-Real-IP"
+)
+
+// ForwardedInfo is the client of a request as seen by the first trusted proxy.
+type ForwardedInfo struct {
+	ClientIP string
+	Scheme   string
+	Host     string
+}
+
+// RealIP resolves the client of requests which went through trusted proxies. The
+// forwarding headers are only believed when the peer is a trusted proxy, and their hops
+// are evaluated from right to left, skipping the trusted ones.
+type RealIP struct {
+	trusted []*net.IPNet
+}
+
+// NewRealIP creates a RealIP trusting the proxies in the networks, such as "10.0.0.0/8"
+// or single addresses.
+func NewRealIP(trustedCIDRs []string) (*RealIP, error) {
+	trusted, err := parseCIDRs(trustedCIDRs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RealIP{trusted: trusted}, nil
+}
+
+// forwardedHop is an element of the Forwarded header, or an address of X-Forwarded-For.
+type forwardedHop struct {
+	ip    net.IP
+	proto string
+	host  string
+}
+
+// Resolve returns the client of r.
+func (ri *RealIP) Resolve(r *http.Request) *ForwardedInfo {
+	info := &ForwardedInfo{
+		ClientIP: remoteIP(r.RemoteAddr),
+		Scheme:   "http",
+		Host:     r.Host,
+	}
+	if r.TLS != nil {
+		info.Scheme = "https"
+	}
+
+	if !ri.isTrusted(net.ParseIP(info.ClientIP)) {
+		return info
+	}
+
+	var hops []forwardedHop
+	if values := r.Header[HeaderForwarded]; len(values) > 0 {
+		hops = parseForwarded(values)
+	} else if values := r.Header[HeaderXForwardedFor]; len(values) > 0 {
+		hops = parseXForwardedFor(values)
+
+		// The nearest proxy appends to the lists last.
+		if proto := lastListValue(r.Header[HeaderXForwardedProto]); proto != "" {
+			info.Scheme = strings.ToLower(proto)
+		}
+		if host := lastListValue(r.Header[HeaderXForwardedHost]); host != "" {
+			info.Host = host
+		}
+	} else if ip := net.ParseIP(strings.TrimSpace(r.Header.Get(HeaderXRealIP))); ip != nil {
+		info.ClientIP = ip.String()
+		return info
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := hops[i]
+		if hop.ip == nil {
+			// An obfuscated or unknown hop, the client is out of reach.
+			break
+		}
+
+		info.ClientIP = hop.ip.String()
+		if hop.proto != "" {
+			info.Scheme = strings.ToLower(hop.proto)
+		}
+		if hop.host != "" {
+			info.Host = hop.host
+		}
+
+		if !ri.isTrusted(hop.ip) {
+			break
+		}
+	}
+
+	return info
+}
+
+func (ri *RealIP) isTrusted(ip net.IP) bool {
+	return ip != nil && containsIP(ri.trusted, ip)
+}
+
+// remoteIP returns the host of a host:port address.
+func remoteIP(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// parseForwarded parses RFC 7239 elements: for=192.0.2.60;proto=http;host=example.com, for="[2001:db8::1]:4711".
+func parseForwarded(values []string) []forwardedHop {
+	var hops []forwardedHop
+
+	for _, value := range values {
+		for _, element := range splitQuoted(value, ',') {
+			var hop forwardedHop
+
+			for _, pair := range splitQuoted(element, ';') {
+				i := strings.Index(pair, "=")
+				if i < 0 {
+					continue
+				}
+
+				key := strings.ToLower(strings.TrimSpace(pair[:i]))
+				val := strings.Trim(strings.TrimSpace(pair[i+1:]), `"`)
+
+				switch key {
+				case "for":
+					hop.ip = parseNodeIP(val)
+				case "proto":
+					hop.proto = val
+				case "host":
+					hop.host = val
+				}
+			}
+
+			hops = append(hops, hop)
+		}
+	}
+
+	return hops
+}
+
+func parseXForwardedFor(values []string) []forwardedHop {
+	var hops []forwardedHop
+
+	for _, value := range values {
+		for _, addr := range strings.Split(value, ",") {
+			hops = append(hops, forwardedHop{ip: parseNodeIP(strings.TrimSpace(addr))})
+		}
+	}
+
+	return hops
+}
+
+// parseNodeIP parses a node such as 192.0.2.43, 192.0.2.43:47011 or [2001:db8::1]:4711.
+func parseNodeIP(node string) net.IP {
+	if ip := net.ParseIP(node); ip != nil {
+		return ip
+	}
+
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		return net.ParseIP(host)
+	}
+
+	return net.ParseIP(strings.Trim(node, "[]"))
+}
+
+// splitQuoted splits s by sep outside of double quotes.
+func splitQuoted(s string, sep byte) []string {
+	var (
+		parts  []string
+		quoted bool
+		start  int
+	)
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case sep:
+			if !quoted {
+				parts = append(parts, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+
+	return append(parts, strings.TrimSpace(s[start:]))
+}
+
+func lastListValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	list := strings.Split(values[len(values)-1], ",")
+	return strings.TrimSpace(list[len(list)-1])
+}
+
+// WithForwardedInfo returns a shallow copy of r carrying the resolved client.
+func WithForwardedInfo(r *http.Request, info *ForwardedInfo) *http.Request {
+	return withValue(r, forwardedKey, info)
+}
+
+func forwardedInfo(r *http.Request) *ForwardedInfo {
+	if r == nil {
+		return nil
+	}
+
+	info, _ := r.Context().Value(forwardedKey).(*ForwardedInfo)
+	return info
+}
+
+// ClientIP returns the IP address of the client, resolved through the trusted proxies by
+// the RealIP middleware, or the address of the peer without it.
+func (c *Context) ClientIP() string {
+	if info := forwardedInfo(c.request); info != nil {
+		return info.ClientIP
+	}
+	return remoteIP(c.request.RemoteAddr)
+}
+
+// Scheme returns the scheme the client used, "http" or "https".
+func (c *Context) Scheme() string {
+	if info := forwardedInfo(c.request); info != nil {
+		return info.Scheme
+	}
+
+	if c.request.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// Host returns the host the client requested.
+func (c *Context) Host() string {
+	if info := forwardedInfo(c.request); info != nil {
+		return info.Host
+	}
+	return c.request.Host
+}
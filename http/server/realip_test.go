@@ -0,0 +1,54 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRealIPResolve(t *testing.T) {
+	ri, err := NewRealIP([]string{"10.0.0.0/8", "2001:db8::1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		remote  string
+		headers map[string]string
+		ip      string
+		scheme  string
+		host    string
+	}{
+		// Untrusted peers can not spoof their address.
+		{"203.0.113.9:1234", map[string]string{HeaderXForwardedFor: "1.1.1.1"}, "203.0.113.9", "http", "api.test"},
+		{"10.0.0.1:1234", map[string]string{HeaderXForwardedFor: "1.1.1.1, 198.51.100.2, 10.0.0.2"}, "198.51.100.2", "http", "api.test"},
+		{"10.0.0.1:1234", map[string]string{HeaderXForwardedFor: "10.0.0.3, 10.0.0.2"}, "10.0.0.3", "http", "api.test"},
+		{"10.0.0.1:1234", map[string]string{
+			HeaderXForwardedFor:   "198.51.100.2",
+			HeaderXForwardedProto: "https",
+			HeaderXForwardedHost:  "www.test",
+		}, "198.51.100.2", "https", "www.test"},
+		{"10.0.0.1:1234", map[string]string{
+			HeaderForwarded: `for=1.1.1.1, for="[2001:db8::7]:4711";proto=https;host=www.test, for=10.0.0.2`,
+		}, "2001:db8::7", "https", "www.test"},
+		{"10.0.0.1:1234", map[string]string{HeaderForwarded: "for=_hidden, for=10.0.0.2"}, "10.0.0.2", "http", "api.test"},
+		{"[2001:db8::1]:443", map[string]string{HeaderXRealIP: "198.51.100.3"}, "198.51.100.3", "http", "api.test"},
+	}
+
+	for i, c := range cases {
+		r := httptest.NewRequest("GET", "http://api.test/", nil)
+		r.RemoteAddr = c.remote
+		for k, v := range c.headers {
+			r.Header.Set(k, v)
+		}
+
+		ctx := NewContext(nil, WithForwardedInfo(r, ri.Resolve(r)))
+		if ctx.ClientIP() != c.ip || ctx.Scheme() != c.scheme || ctx.Host() != c.host {
+			t.Errorf("case %d: got %s %s %s", i, ctx.ClientIP(), ctx.Scheme(), ctx.Host())
+		}
+	}
+}
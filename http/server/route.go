@@ -0,0 +1,79 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package server
+
+import (
+	"github.com/gorilla/mux"
+)
+
+// Route describes a registered route.
+type Route struct {
+	Methods []string `json:"methods,omitempty"`
+	Pattern string   `json:"pattern"`
+	// Version is the API version of the route, 0 if it is not versioned.
+	Version   int        `json:"version,omitempty"`
+	Operation *Operation `json:"operation,omitempty"`
+
+	router    *Router
+	muxRoutes []*mux.Route
+}
+
+// Operation documents a route, for the generation of an OpenAPI document:
+//
+//	rt.Post("/users", createUser).Describe(server.Operation{
+//		Summary:   "Create a user",
+//		Tags:      []string{"users"},
+//		Request:   CreateUserRequest{},
+//		Responses: map[int]interface{}{201: User{}, 409: Error{}},
+//	})
+type Operation struct {
+	OperationID string   `json:"operation_id,omitempty"`
+	Summary     string   `json:"summary,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Deprecated  bool     `json:"deprecated,omitempty"`
+	// Hidden leaves the route out of the OpenAPI document.
+	Hidden bool `json:"hidden,omitempty"`
+
+	// Parameters are the query, header and cookie parameters, the path parameters are
+	// taken from the pattern unless they are listed.
+	Parameters []Parameter `json:"parameters,omitempty"`
+	// Request is a value of the type of the JSON request body, such as CreateUserRequest{}.
+	Request interface{} `json:"-"`
+	// Responses are values of the types of the JSON response bodies by status code, a nil
+	// value is a response without body.
+	Responses map[int]interface{} `json:"-"`
+}
+
+// Parameter documents a parameter of an Operation.
+type Parameter struct {
+	Name string `json:"name"`
+	// In is one of "query", "header", "path" and "cookie".
+	In          string `json:"in"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+	// Type is a value of the Go type of the parameter, such as 0 for an integer, a
+	// string if it is nil.
+	Type interface{} `json:"-"`
+}
+
+// Describe attaches the documentation of the route.
+func (r *Route) Describe(op Operation) *Route {
+	r.Operation = &op
+	return r
+}
+
+// Routes returns the registered routes in registration order.
+func (rt *Router) Routes() []Route {
+	rt.mu.RLock()
+	defer rt.mu.RUnlock()
+
+	routes := make([]Route, len(rt.routes))
+	for i, r := range rt.routes {
+		routes[i] = *r
+	}
+	return routes
+}
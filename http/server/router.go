@@ -17,6 +17,15 @@ type Router struct {
 	router     *mux.Router
 	ctxPool    sync.Pool
 	errHandler func(*Context)
+	jsonOpts   JSONOptions
+	mu         sync.RWMutex
+	routes     []*Route
+
+	versioning   VersionOptions
+	versioned    map[string]*versionedRoute
+	deprecations map[int]Deprecation
+
+	bodyLimits map[*mux.Route]BodyOptions
 }
 
 // NewRouter returns a router.
@@ -38,7 +47,12 @@ func NewRouter() *Router {
 
 // Handler returns a http.Handler.
 func (rt *Router) Handler() http.Handler {
-	return rt.router
+	return rt
+}
+
+// ServeHTTP dispatches the request to the handler of the matched route.
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt.router.ServeHTTP(w, r)
 }
 
 // SetErrorHandler attach a global error handler on router.
@@ -46,22 +60,75 @@ func (rt *Router) SetErrorHandler(h func(*Context)) {
 	rt.errHandler = h
 }
 
+// SetJSONOptions sets the options of Context.JSONBody for every route.
+func (rt *Router) SetJSONOptions(opt JSONOptions) {
+	rt.jsonOpts = opt
+}
+
 // Get adds a route path access via GET method.
-func (rt *Router) Get(pattern string, handler HandlerFunc, filters ...FilterFunc) {
-	rt.router.HandleFunc(pattern, rt.wrapHandlerFunc(handler, filters...)).Methods("GET")
+func (rt *Router) Get(pattern string, handler HandlerFunc, filters ...FilterFunc) *Route {
+	return rt.handle(pattern, rt.wrapHandlerFunc(handler, filters...), GET)
 }
 
 // Post adds a route path access via POST method.
-func (rt *Router) Post(pattern string, handler HandlerFunc, filters ...FilterFunc) {
-	rt.router.HandleFunc(pattern, rt.wrapHandlerFunc(handler, filters...)).Methods("POST")
+func (rt *Router) Post(pattern string, handler HandlerFunc, filters ...FilterFunc) *Route {
+	return rt.handle(pattern, rt.wrapHandlerFunc(handler, filters...), POST)
+}
+
+// Put adds a route path access via PUT method.
+func (rt *Router) Put(pattern string, handler HandlerFunc, filters ...FilterFunc) *Route {
+	return rt.handle(pattern, rt.wrapHandlerFunc(handler, filters...), PUT)
+}
+
+// Patch adds a route path access via PATCH method.
+func (rt *Router) Patch(pattern string, handler HandlerFunc, filters ...FilterFunc) *Route {
+	return rt.handle(pattern, rt.wrapHandlerFunc(handler, filters...), PATCH)
+}
+
+// Delete adds a route path access via DELETE method.
+func (rt *Router) Delete(pattern string, handler HandlerFunc, filters ...FilterFunc) *Route {
+	return rt.handle(pattern, rt.wrapHandlerFunc(handler, filters...), DELETE)
+}
+
+// Any adds a route path access via every method, such as a proxy.
+func (rt *Router) Any(pattern string, handler HandlerFunc, filters ...FilterFunc) *Route {
+	return rt.handle(pattern, rt.wrapHandlerFunc(handler, filters...))
+}
+
+// Handle adds a route served by a http.Handler, such as a metrics or a profiling handler,
+// for the methods, or for every method if there are none.
+func (rt *Router) Handle(pattern string, handler http.Handler, methods ...string) *Route {
+	return rt.handle(pattern, func(w http.ResponseWriter, r *http.Request) {
+		recordRoute(r)
+		handler.ServeHTTP(w, r)
+	}, methods...)
+}
+
+func (rt *Router) handle(pattern string, f http.HandlerFunc, methods ...string) *Route {
+	route := rt.router.NewRoute().Path(pattern)
+	route.HandlerFunc(rt.limitBody(route, f))
+	if len(methods) > 0 {
+		route.Methods(methods...)
+	}
+
+	r := &Route{Methods: methods, Pattern: pattern, router: rt, muxRoutes: []*mux.Route{route}}
+
+	rt.mu.Lock()
+	rt.routes = append(rt.routes, r)
+	rt.mu.Unlock()
+
+	return r
 }
 
 // Wraps a HandlerFunc to a http.HandlerFunc.
 func (rt *Router) wrapHandlerFunc(f HandlerFunc, filters ...FilterFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		recordRoute(r)
+
 		c := rt.ctxPool.Get().(*Context)
 		defer rt.ctxPool.Put(c)
 		c.Reset(w, r)
+		c.jsonOptions = rt.jsonOpts
 
 		if len(filters) > 0 {
 			for _, filter := range filters {
@@ -74,11 +141,25 @@ func (rt *Router) wrapHandlerFunc(f HandlerFunc, filters ...FilterFunc) http.Han
 
 		if err := f(c); err != nil {
 			c.LastError = err
+
+			// A body over its limit is answered even if the handler only returns the error.
+			if status, ok := bodyErrorStatus(err); ok && !written(w) {
+				http.Error(w, err.Error(), status)
+				return
+			}
 			rt.errHandler(c)
 		}
 	}
 }
 
+// written reports whether the response was started, as far as the writer tells.
+func written(w http.ResponseWriter) bool {
+	rw, ok := w.(interface {
+		Written() bool
+	})
+	return ok && rw.Written()
+}
+
 // MethodNotAllowedHandler returns a simple request handler
 // that replies to each request with a ``405 method not allowed'' reply.
 func MethodNotAllowedHandler() http.Handler {
@@ -7,31 +7,45 @@ package server
 
 import (
 	"context"
-	"crypto/tls"
 	"errors"
-	"net"
+	"fmt"
 	"net/http"
 	"os"
+	"sync"
 	"time"
-	"fmt"
 
 	"github.com/urfave/negroni"
 )
 
+const (
+	defaultShutdownTimeout = 3 * time.Second
+	errorsBacklog          = 8
+)
+
 var (
 	errNoRouter  = errors.New("entrypoint requires a router")
 	errTLSConfig = errors.New("cert or key file in the TLS configuration does not exist")
+
+	errAlreadyStarted = errors.New("entrypoint is already started")
+	errNotRunning     = errors.New("entrypoint is not running")
 )
 
 // Entrypoint represents a http server.
 type Entrypoint struct {
 	configuration *Configuration
 	tlsConfig     *TLSConfiguration
-	server        *http.Server
-	listener      net.Listener
+	mu            sync.Mutex
+	listeners     []*listener
 	middlewares   []negroni.Handler
+	startHooks    []hook
+	shutdownHooks []hook
+	state         int32
+	stopOnce      sync.Once
 	stop          chan bool
 	signals       chan os.Signal
+	errs          chan error
+	errMu         sync.Mutex
+	err           error
 }
 
 // NewEntrypoint creates a new Entrypoint.
@@ -40,6 +54,7 @@ func NewEntrypoint(conf *Configuration, tlsConf *TLSConfiguration) *Entrypoint {
 		configuration: conf,
 		tlsConfig:     tlsConf,
 		stop:          make(chan bool, 1),
+		errs:          make(chan error, errorsBacklog),
 		signals:       make(chan os.Signal, 1),
 		middlewares:   []negroni.Handler{},
 	}
@@ -47,62 +62,40 @@ func NewEntrypoint(conf *Configuration, tlsConf *TLSConfiguration) *Entrypoint {
 
 // Prepare the entrypoint for serving requests.
 func (ep *Entrypoint) prepare(router http.Handler) error {
-	var (
-		err       error
-		tlsConfig *tls.Config
-		listener  net.Listener
-	)
-
-	if tlsConfig, err = ep.createTLSConfig(); err != nil {
-		return err
+	handler := ep.buildRouter(router)
+
+	for _, conf := range ep.listenerConfigurations() {
+		l, err := ep.newListener(conf, handler)
+		if err != nil {
+			ep.closeListeners()
+			return err
+		}
+		ep.listeners = append(ep.listeners, l)
 	}
 
-	listener, err = net.Listen("tcp", ep.configuration.Address)
-	if err != nil {
-		return err
-	}
+	if conf := ep.configuration.Admin; conf != nil {
+		rt, _ := router.(*Router)
 
-	ep.listener = listener
-	ep.server = &http.Server{
-		Addr:      ep.configuration.Address,
-		Handler:   ep.buildRouter(router),
-		TLSConfig: tlsConfig,
+		l, err := ep.newAdminListener(conf, rt)
+		if err != nil {
+			ep.closeListeners()
+			return err
+		}
+		ep.listeners = append(ep.listeners, l)
 	}
 
 	return nil
 }
 
-// Create the TLS Configuration for the http server.
-func (ep *Entrypoint) createTLSConfig() (*tls.Config, error) {
-	var (
-		err    error
-		exists bool
-	)
-
-	if ep.tlsConfig == nil {
-		return nil, nil
-	}
-
-	if exists, err = FileExists(ep.tlsConfig.Cert); !exists {
-		return nil, errTLSConfig
+// closeListeners closes the sockets of a server which did not start.
+func (ep *Entrypoint) closeListeners() {
+	for _, l := range ep.listeners {
+		l.listener.Close()
 	}
-
-	if exists, err = FileExists(ep.tlsConfig.Key); !exists {
-		return nil, errTLSConfig
-	}
-
-	cert, err := tls.LoadX509KeyPair(ep.tlsConfig.Cert, ep.tlsConfig.Key)
-	if err != nil {
-		return nil, err
-	}
-
-	config := &tls.Config{
-		Certificates: []tls.Certificate{cert},
-	}
-
-	return config, nil
+	ep.listeners = nil
 }
 
+// FileExists reports whether the named file exists.
 func FileExists(filename string) (bool, error) {
 	if _, err := os.Stat(filename); os.IsNotExist(err) {
 		return false, nil
@@ -112,9 +105,27 @@ func FileExists(filename string) (bool, error) {
 	return true, nil
 }
 
+// Handler returns router wrapped in the middlewares of the entrypoint, as it is served.
+func (ep *Entrypoint) Handler(router http.Handler) http.Handler {
+	return ep.buildRouter(router)
+}
+
 func (ep *Entrypoint) buildRouter(router http.Handler) http.Handler {
 	n := negroni.New()
 
+	if conf := ep.configuration; conf.MaxBodyBytes > 0 || conf.DecompressBody {
+		var routes func(*http.Request) (BodyOptions, bool)
+		if rt, ok := router.(*Router); ok {
+			routes = rt.matchBodyLimit
+		}
+
+		n.Use(bodyHandler(BodyOptions{
+			MaxBytes:   conf.MaxBodyBytes,
+			Decompress: conf.DecompressBody,
+			MaxRatio:   conf.MaxDecompressionRatio,
+		}, routes))
+	}
+
 	for _, mw := range ep.middlewares {
 		n.Use(mw)
 	}
@@ -124,12 +135,44 @@ func (ep *Entrypoint) buildRouter(router http.Handler) http.Handler {
 	return n
 }
 
-func (ep *Entrypoint) startServer() error {
-	if ep.tlsConfig != nil {
-		return ep.server.ServeTLS(ep.listener, "", "")
+// serve runs the server of a listener until it is closed, a failure stops the entrypoint.
+func (ep *Entrypoint) serve(l *listener) {
+	err := l.serve()
+	if err == nil || err == http.ErrServerClosed {
+		return
 	}
 
-	return ep.server.Serve(ep.listener)
+	ep.fail(fmt.Errorf("%s: %v", l, err))
+	go ep.Stop()
+}
+
+// fail records err as the entrypoint error if it is the first one, and publishes it on Errors.
+func (ep *Entrypoint) fail(err error) {
+	Logf(LevelError, "Entrypoint serve: %v", err)
+
+	ep.errMu.Lock()
+	if ep.err == nil {
+		ep.err = err
+	}
+	ep.errMu.Unlock()
+
+	select {
+	case ep.errs <- err:
+	default:
+	}
+}
+
+// Errors returns a channel receiving the asynchronous failures of the server.
+// http.ErrServerClosed is not a failure and is never sent.
+func (ep *Entrypoint) Errors() <-chan error {
+	return ep.errs
+}
+
+// Err returns the first fatal error of the server, or nil.
+func (ep *Entrypoint) Err() error {
+	ep.errMu.Lock()
+	defer ep.errMu.Unlock()
+	return ep.err
 }
 
 // AttachMiddleware attach a new middleware on entrypoint.
@@ -143,39 +186,121 @@ func (ep *Entrypoint) Start(router http.Handler) error {
 		return errNoRouter
 	}
 
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+
+	if ep.State() != StateIdle {
+		return errAlreadyStarted
+	}
+
 	if err := ep.prepare(router); err != nil {
 		return err
 	}
 
+	ep.setState(StateStarting)
+	for i := range ep.startHooks {
+		if err := ep.startHooks[i].run(); err != nil {
+			ep.closeListeners()
+			ep.setState(StateIdle)
+			return err
+		}
+	}
+
 	ep.configureSignals()
 
 	go ep.listenSignals()
-	go ep.startServer()
 
-	fmt.Println("Serving on:", ep.configuration.Address)
+	for _, l := range ep.listeners {
+		go ep.watchCertificates(l)
+		go ep.serve(l)
+	}
+
+	ep.setState(StateRunning)
+	for _, l := range ep.listeners {
+		fmt.Println("Serving on:", l)
+	}
+	notifyReady()
 
 	return nil
 }
 
-// Run until stop channel emits a value.
-func (ep *Entrypoint) Run() {
+// Run until stop channel emits a value, returns the first fatal server error.
+func (ep *Entrypoint) Run() error {
 	<-ep.stop
+	return ep.Err()
+}
+
+// Wait until stop channel emits a value, returns the first fatal server error.
+func (ep *Entrypoint) Wait() error {
+	return ep.Run()
 }
 
-// Wait until stop channel emits a value.
-func (ep *Entrypoint) Wait() {
-	ep.Run()
+// ListenAndServe starts the entrypoint and runs until it stops, returns the error
+// which prevents it from starting or the first fatal server error.
+func (ep *Entrypoint) ListenAndServe(router http.Handler) error {
+	if err := ep.Start(router); err != nil {
+		return err
+	}
+
+	return ep.Run()
 }
 
-// Stop the http server.
+// Stop the http server. The entrypoint reports StateDraining, waits for ShutdownDelay,
+// drains the connections for up to ShutdownTimeout, then runs the shutdown hooks.
+// It is safe to call Stop more than once, or before Start.
 func (ep *Entrypoint) Stop() {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ep.stopOnce.Do(ep.shutdown)
+}
+
+func (ep *Entrypoint) shutdown() {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+
+	started := ep.State() != StateIdle
+	ep.setState(StateDraining)
+
+	if len(ep.listeners) > 0 {
+		if ep.configuration.ShutdownDelay > 0 {
+			time.Sleep(ep.configuration.ShutdownDelay)
+		}
 
-	// graceful shutdown
-	if err := ep.server.Shutdown(ctx); err != nil {
-		ep.server.Close()
+		ep.shutdownServers()
 	}
-	cancel()
 
+	if started {
+		for i := range ep.shutdownHooks {
+			if err := ep.shutdownHooks[i].run(); err != nil {
+				Logf(LevelError, "Entrypoint shutdown: %v", err)
+			}
+		}
+	}
+
+	ep.setState(StateStopped)
 	close(ep.stop)
 }
+
+// shutdownServers drains all servers concurrently, within one ShutdownTimeout.
+func (ep *Entrypoint) shutdownServers() {
+	timeout := ep.configuration.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, l := range ep.listeners {
+		wg.Add(1)
+		go func(l *listener) {
+			defer wg.Done()
+
+			// graceful shutdown
+			if err := l.server.Shutdown(ctx); err != nil {
+				Logf(LevelError, "Entrypoint shutdown: %v %v", l, err)
+				l.server.Close()
+			}
+		}(l)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,231 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package server
+
+import (
+	"bufio"
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEntrypointStopBeforeStart(t *testing.T) {
+	ep := NewEntrypoint(&Configuration{Address: "127.0.0.1:0"}, nil)
+
+	ep.Stop()
+	ep.Stop()
+
+	if ep.State() != StateStopped {
+		t.Fatalf("unexpected state %s", ep.State())
+	}
+}
+
+func TestEntrypointHooks(t *testing.T) {
+	var order []string
+
+	ep := NewEntrypoint(&Configuration{Address: "127.0.0.1:0"}, nil)
+	record := func(name string) HookFunc {
+		return func(context.Context) error {
+			order = append(order, name)
+			return nil
+		}
+	}
+
+	ep.OnStart("second", 2, 0, record("start-2"))
+	ep.OnStart("first", 1, 0, record("start-1"))
+	ep.OnShutdown("consumer", 1, time.Second, record("shutdown-1"))
+	ep.OnShutdown("slow", 2, 10*time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	ep.OnShutdown("cron", 3, 0, record("shutdown-3"))
+
+	if err := ep.Start(http.NotFoundHandler()); err != nil {
+		t.Fatal(err)
+	}
+	if !ep.Ready() {
+		t.Fatalf("unexpected state %s", ep.State())
+	}
+
+	ep.Stop()
+	ep.Run()
+
+	expected := []string{"start-1", "start-2", "shutdown-1", "shutdown-3"}
+	if !reflect.DeepEqual(order, expected) {
+		t.Fatalf("unexpected order %v", order)
+	}
+	if ep.Ready() {
+		t.Fatal("entrypoint is still ready")
+	}
+}
+
+func TestEntrypointServeError(t *testing.T) {
+	ep := NewEntrypoint(&Configuration{Address: "127.0.0.1:0"}, nil)
+
+	if err := ep.Start(http.NotFoundHandler()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Closing the listener behind the server's back makes Serve fail.
+	ep.listeners[0].listener.Close()
+
+	select {
+	case err := <-ep.Errors():
+		if err == nil {
+			t.Fatal("expected a serve error")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("serve error was not reported")
+	}
+
+	if err := ep.Run(); err == nil {
+		t.Fatal("Run should return the serve error")
+	}
+}
+
+func TestEntrypointListeners(t *testing.T) {
+	dir, err := ioutil.TempDir("", "apix-listeners")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "apix.sock")
+	ep := NewEntrypoint(&Configuration{
+		Listeners: []ListenerConfiguration{
+			{Network: "unix", Address: socket, FileMode: 0600},
+			{Address: "127.0.0.1:0", RedirectPort: 8443},
+		},
+	}, nil)
+
+	if err = ep.Start(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})); err != nil {
+		t.Fatal(err)
+	}
+	defer ep.Stop()
+
+	info, err := os.Stat(socket)
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("unexpected socket file %v %v", info, err)
+	}
+
+	unixClient := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return net.Dial("unix", socket)
+		},
+	}}
+	resp, err := unixClient.Get("http://unix/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "ok" {
+		t.Fatalf("unexpected body %q", body)
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	addr := ep.listeners[1].listener.Addr().String()
+	resp, err = client.Get("http://" + addr + "/path?q=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if location := resp.Header.Get(HeaderLocation); location != "https://127.0.0.1:8443/path?q=1" {
+		t.Fatalf("unexpected redirect %d %s", resp.StatusCode, location)
+	}
+}
+
+func TestEntrypointServerConfiguration(t *testing.T) {
+	ep := NewEntrypoint(&Configuration{
+		Address:           "127.0.0.1:0",
+		ReadTimeout:       1 * time.Second,
+		ReadHeaderTimeout: 2 * time.Second,
+		WriteTimeout:      3 * time.Second,
+		IdleTimeout:       4 * time.Second,
+		MaxHeaderBytes:    4096,
+		DisableKeepAlives: true,
+	}, nil)
+
+	if err := ep.Start(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})); err != nil {
+		t.Fatal(err)
+	}
+	defer ep.Stop()
+
+	s := ep.listeners[0].server
+	if s.ReadTimeout != time.Second || s.ReadHeaderTimeout != 2*time.Second || s.WriteTimeout != 3*time.Second || s.IdleTimeout != 4*time.Second || s.MaxHeaderBytes != 4096 {
+		t.Fatalf("unexpected server %+v", s)
+	}
+
+	resp, err := http.Get("http://" + ep.listeners[0].listener.Addr().String() + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if !resp.Close {
+		t.Fatal("the connection was kept alive")
+	}
+}
+
+func TestEntrypointMaxConnections(t *testing.T) {
+	entered := make(chan struct{}, 2)
+	release := make(chan struct{})
+
+	ep := NewEntrypoint(&Configuration{Address: "127.0.0.1:0", MaxConnections: 1}, nil)
+	if err := ep.Start(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+	})); err != nil {
+		t.Fatal(err)
+	}
+	defer ep.Stop()
+
+	addr := ep.listeners[0].listener.Addr().String()
+	request := func() net.Conn {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = conn.Write([]byte("GET / HTTP/1.1\r\nHost: apix\r\n\r\n")); err != nil {
+			t.Fatal(err)
+		}
+		return conn
+	}
+
+	first := request()
+	<-entered
+
+	// The second connection waits in the backlog while the first one holds the only slot.
+	second := request()
+	defer second.Close()
+
+	select {
+	case <-entered:
+		t.Fatal("a connection over the limit was served")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if _, err := http.ReadResponse(bufio.NewReader(first), nil); err != nil {
+		t.Fatal(err)
+	}
+	first.Close()
+
+	<-entered
+	if _, err := http.ReadResponse(bufio.NewReader(second), nil); err != nil {
+		t.Fatal(err)
+	}
+}
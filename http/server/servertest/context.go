@@ -0,0 +1,40 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package servertest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/TechCatsLab/apix/http/server"
+	"github.com/gorilla/mux"
+)
+
+// NewTestContext returns a Context for a request and the recorder of its response, for
+// calling a HandlerFunc directly:
+//
+//	c, rec := servertest.NewTestContext("GET", "/users/1", nil)
+//	err := getUser(c)
+func NewTestContext(method, target string, body io.Reader) (*server.Context, *httptest.ResponseRecorder) {
+	rec := httptest.NewRecorder()
+	return server.NewContext(rec, httptest.NewRequest(method, target, body)), rec
+}
+
+// WithRoute returns r as routed by a route registered with pattern, such as "/users/{id}",
+// so that mux.Vars and Context.RoutePattern work in a handler called directly. It returns
+// r if the pattern does not match.
+func WithRoute(r *http.Request, pattern string) *http.Request {
+	routed := r
+
+	router := mux.NewRouter()
+	router.HandleFunc(pattern, func(_ http.ResponseWriter, req *http.Request) {
+		routed = req
+	})
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	return routed
+}
@@ -0,0 +1,181 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package servertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Response is the recorded response of a request, its assertions fail the test.
+type Response struct {
+	Recorder *httptest.ResponseRecorder
+
+	t       testing.TB
+	request *http.Request
+	decoded interface{}
+}
+
+func (r *Response) fatalf(format string, args ...interface{}) {
+	r.t.Helper()
+	r.t.Fatalf("%s %s: %s", r.request.Method, r.request.URL, fmt.Sprintf(format, args...))
+}
+
+// Code returns the status code.
+func (r *Response) Code() int {
+	return r.Recorder.Code
+}
+
+// Body returns the body.
+func (r *Response) Body() string {
+	return r.Recorder.Body.String()
+}
+
+// Status asserts the status code.
+func (r *Response) Status(code int) *Response {
+	r.t.Helper()
+
+	if r.Recorder.Code != code {
+		r.fatalf("status %d, expected %d, body %q", r.Recorder.Code, code, r.Body())
+	}
+	return r
+}
+
+// Header asserts the value of a response header.
+func (r *Response) Header(key, value string) *Response {
+	r.t.Helper()
+
+	if got := r.Recorder.Header().Get(key); got != value {
+		r.fatalf("header %s is %q, expected %q", key, got, value)
+	}
+	return r
+}
+
+// HeaderPresent asserts a response header is set.
+func (r *Response) HeaderPresent(key string) *Response {
+	r.t.Helper()
+
+	if _, ok := r.Recorder.Header()[http.CanonicalHeaderKey(key)]; !ok {
+		r.fatalf("header %s is missing", key)
+	}
+	return r
+}
+
+// BodyContains asserts the body contains s.
+func (r *Response) BodyContains(s string) *Response {
+	r.t.Helper()
+
+	if !strings.Contains(r.Body(), s) {
+		r.fatalf("body %q does not contain %q", r.Body(), s)
+	}
+	return r
+}
+
+// DecodeJSON decodes the JSON body into v.
+func (r *Response) DecodeJSON(v interface{}) *Response {
+	r.t.Helper()
+
+	if err := json.Unmarshal(r.Recorder.Body.Bytes(), v); err != nil {
+		r.fatalf("invalid JSON body %q: %v", r.Body(), err)
+	}
+	return r
+}
+
+// JSONPath asserts the value at a path of the JSON body, such as "data.items[0].name" or
+// "data.items.0.name". The expected value is compared with its JSON encoding, so 1 and
+// 1.0 are equal, and a struct equals the object of its fields.
+func (r *Response) JSONPath(path string, expected interface{}) *Response {
+	r.t.Helper()
+
+	got, err := r.lookup(path)
+	if err != nil {
+		r.fatalf("%v", err)
+	}
+
+	want, err := normalize(expected)
+	if err != nil {
+		r.fatalf("invalid expected value for %s: %v", path, err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		r.fatalf("%s is %s, expected %s", path, encode(got), encode(want))
+	}
+	return r
+}
+
+// JSONPathExists asserts a path of the JSON body exists.
+func (r *Response) JSONPathExists(path string) *Response {
+	r.t.Helper()
+
+	if _, err := r.lookup(path); err != nil {
+		r.fatalf("%v", err)
+	}
+	return r
+}
+
+func (r *Response) lookup(path string) (interface{}, error) {
+	if r.decoded == nil {
+		if err := json.Unmarshal(r.Recorder.Body.Bytes(), &r.decoded); err != nil {
+			return nil, fmt.Errorf("invalid JSON body %q: %v", r.Body(), err)
+		}
+	}
+
+	return lookupPath(r.decoded, path)
+}
+
+// lookupPath walks the decoded value v along the dotted path.
+func lookupPath(v interface{}, path string) (interface{}, error) {
+	path = strings.Replace(strings.Replace(path, "[", ".", -1), "]", "", -1)
+	if path == "" || path == "." {
+		return v, nil
+	}
+
+	walked := ""
+	for _, key := range strings.Split(strings.TrimPrefix(path, "."), ".") {
+		walked = strings.TrimPrefix(walked+"."+key, ".")
+
+		switch node := v.(type) {
+		case map[string]interface{}:
+			child, ok := node[key]
+			if !ok {
+				return nil, fmt.Errorf("%s does not exist", walked)
+			}
+			v = child
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("%s is out of the %d items", walked, len(node))
+			}
+			v = node[i]
+		default:
+			return nil, fmt.Errorf("%s does not exist, the parent is %s", walked, encode(node))
+		}
+	}
+
+	return v, nil
+}
+
+func normalize(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var n interface{}
+	err = json.Unmarshal(b, &n)
+	return n, err
+}
+
+func encode(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,210 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+// Package servertest sends requests to a server.Router, or to the handler composed by an
+// Entrypoint with its middlewares, in process:
+//
+//	s := servertest.New(rt)
+//	s.Post("/users").JSON(user).JWT(key, claims).Do(t).
+//		Status(http.StatusCreated).
+//		Header("Location", "/users/1").
+//		JSONPath("data.name", "Ada")
+package servertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/TechCatsLab/apix/http/server"
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Server sends requests to a handler in process.
+type Server struct {
+	handler http.Handler
+	headers http.Header
+}
+
+// New returns a Server sending requests to h, such as a server.Router.
+func New(h http.Handler) *Server {
+	return &Server{handler: h, headers: make(http.Header)}
+}
+
+// NewEntrypoint returns a Server sending requests to router through the middlewares of
+// ep, as ep serves them. ep is not started.
+func NewEntrypoint(ep *server.Entrypoint, router http.Handler) *Server {
+	return New(ep.Handler(router))
+}
+
+// SetHeader sets a header sent with every request.
+func (s *Server) SetHeader(key, value string) *Server {
+	s.headers.Set(key, value)
+	return s
+}
+
+// Get builds a GET request.
+func (s *Server) Get(target string) *Request {
+	return s.Request(server.GET, target)
+}
+
+// Post builds a POST request.
+func (s *Server) Post(target string) *Request {
+	return s.Request(server.POST, target)
+}
+
+// Put builds a PUT request.
+func (s *Server) Put(target string) *Request {
+	return s.Request(server.PUT, target)
+}
+
+// Patch builds a PATCH request.
+func (s *Server) Patch(target string) *Request {
+	return s.Request(server.PATCH, target)
+}
+
+// Delete builds a DELETE request.
+func (s *Server) Delete(target string) *Request {
+	return s.Request(server.DELETE, target)
+}
+
+// Request builds a request of any method.
+func (s *Server) Request(method, target string) *Request {
+	r := &Request{
+		server:  s,
+		method:  method,
+		target:  target,
+		headers: make(http.Header),
+		query:   make(url.Values),
+	}
+	for key, values := range s.headers {
+		r.headers[key] = append([]string(nil), values...)
+	}
+	return r
+}
+
+// Request is a request being built, the first error is reported by Do.
+type Request struct {
+	server     *Server
+	method     string
+	target     string
+	headers    http.Header
+	query      url.Values
+	cookies    []*http.Cookie
+	body       io.Reader
+	remoteAddr string
+	err        error
+}
+
+// Header sets a request header.
+func (r *Request) Header(key, value string) *Request {
+	r.headers.Set(key, value)
+	return r
+}
+
+// Query adds a query parameter.
+func (r *Request) Query(key, value string) *Request {
+	r.query.Add(key, value)
+	return r
+}
+
+// Cookie adds a cookie.
+func (r *Request) Cookie(c *http.Cookie) *Request {
+	r.cookies = append(r.cookies, c)
+	return r
+}
+
+// RemoteAddr sets the address of the client, such as "192.0.2.1:1234".
+func (r *Request) RemoteAddr(addr string) *Request {
+	r.remoteAddr = addr
+	return r
+}
+
+// Body sets the request body and its content type.
+func (r *Request) Body(body io.Reader, contentType string) *Request {
+	r.body = body
+	if contentType != "" {
+		r.headers.Set(server.HeaderContentType, contentType)
+	}
+	return r
+}
+
+// JSON sets the body to v encoded in JSON.
+func (r *Request) JSON(v interface{}) *Request {
+	b, err := json.Marshal(v)
+	if err != nil && r.err == nil {
+		r.err = err
+	}
+	return r.Body(bytes.NewReader(b), server.MIMEApplicationJSON)
+}
+
+// Form sets the body to the URL encoded form values.
+func (r *Request) Form(values url.Values) *Request {
+	return r.Body(strings.NewReader(values.Encode()), "application/x-www-form-urlencoded")
+}
+
+// BearerToken sets the Authorization header to a bearer token.
+func (r *Request) BearerToken(token string) *Request {
+	return r.Header(server.HeaderAuthorization, "Bearer "+token)
+}
+
+// JWT signs the claims with key using HS256 and sends the token as a bearer token, as
+// expected by the jwt middleware.
+func (r *Request) JWT(key string, claims jwt.Claims) *Request {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(key))
+	if err != nil && r.err == nil {
+		r.err = err
+	}
+	return r.BearerToken(token)
+}
+
+// Build returns the http.Request.
+func (r *Request) Build() (*http.Request, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	target := r.target
+	if len(r.query) > 0 {
+		sep := "?"
+		if strings.Contains(target, "?") {
+			sep = "&"
+		}
+		target += sep + r.query.Encode()
+	}
+
+	req := httptest.NewRequest(r.method, target, r.body)
+	for key, values := range r.headers {
+		req.Header[key] = values
+	}
+	for _, c := range r.cookies {
+		req.AddCookie(c)
+	}
+	if r.remoteAddr != "" {
+		req.RemoteAddr = r.remoteAddr
+	}
+
+	return req, nil
+}
+
+// Do sends the request, a failure to build it fails the test.
+func (r *Request) Do(t testing.TB) *Response {
+	t.Helper()
+
+	req, err := r.Build()
+	if err != nil {
+		t.Fatalf("%s %s: %v", r.method, r.target, err)
+	}
+
+	rec := httptest.NewRecorder()
+	r.server.handler.ServeHTTP(rec, req)
+
+	return &Response{t: t, Recorder: rec, request: req}
+}
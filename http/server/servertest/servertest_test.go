@@ -0,0 +1,119 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package servertest
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/TechCatsLab/apix/http/server"
+	"github.com/TechCatsLab/apix/http/server/middleware"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/mux"
+)
+
+type user struct {
+	ID    string   `json:"id"`
+	Name  string   `json:"name" validate:"required"`
+	Roles []string `json:"roles"`
+}
+
+func getUser(c *server.Context) error {
+	return c.ServeJSON(map[string]interface{}{
+		"data": user{ID: mux.Vars(c.Request())["id"], Name: "Ada", Roles: []string{"admin"}},
+	})
+}
+
+func createUser(c *server.Context) error {
+	var u user
+	if err := c.JSONBody(&u); err != nil {
+		return c.WriteHeader(http.StatusBadRequest)
+	}
+
+	c.SetHeader("Location", "/users/"+u.Name)
+	c.WriteHeader(http.StatusCreated)
+	return nil
+}
+
+func testRouter() *server.Router {
+	rt := server.NewRouter()
+	rt.Get("/users/{id}", getUser)
+	rt.Post("/users", createUser)
+	return rt
+}
+
+func TestRouter(t *testing.T) {
+	s := New(testRouter())
+
+	s.Get("/users/7").Do(t).
+		Status(http.StatusOK).
+		Header("Content-Type", server.MIMEApplicationJSONCharsetUTF8).
+		JSONPath("data.id", "7").
+		JSONPath("data.roles[0]", "admin").
+		JSONPath("data", user{ID: "7", Name: "Ada", Roles: []string{"admin"}})
+
+	s.Post("/users").JSON(user{Name: "Ada"}).Do(t).
+		Status(http.StatusCreated).
+		Header("Location", "/users/Ada")
+
+	s.Post("/users").Do(t).Status(http.StatusBadRequest)
+}
+
+func TestEntrypoint(t *testing.T) {
+	const key = "secret"
+
+	ep := server.NewEntrypoint(&server.Configuration{}, nil)
+	ep.AttachMiddleware(middleware.NegroniJwtHandler(key, nil, nil, func(w http.ResponseWriter, r *http.Request, err string) {
+		http.Error(w, err, http.StatusUnauthorized)
+	}))
+
+	s := NewEntrypoint(ep, testRouter())
+
+	s.Get("/users/1").Do(t).Status(http.StatusUnauthorized)
+	s.Get("/users/1").JWT(key, jwt.MapClaims{"sub": "1"}).Do(t).
+		Status(http.StatusOK).
+		JSONPath("data.name", "Ada")
+}
+
+func TestNewTestContext(t *testing.T) {
+	c, rec := NewTestContext("GET", "/users/3", nil)
+	c.SetRequest(WithRoute(c.Request(), "/users/{id}"))
+
+	if err := getUser(c); err != nil {
+		t.Fatal(err)
+	}
+	if c.RoutePattern() != "/users/{id}" {
+		t.Fatalf("unexpected route pattern %q", c.RoutePattern())
+	}
+
+	v, err := lookupPath(decode(t, rec.Body.Bytes()), "data.id")
+	if err != nil || v != "3" {
+		t.Fatalf("unexpected id %v: %v", v, err)
+	}
+}
+
+func TestLookupPath(t *testing.T) {
+	doc := decode(t, []byte(`{"a":{"b":[{"c":1}]}}`))
+
+	if v, err := lookupPath(doc, "a.b[0].c"); err != nil || v != float64(1) {
+		t.Fatalf("unexpected value %v: %v", v, err)
+	}
+	if _, err := lookupPath(doc, "a.b[1]"); err == nil {
+		t.Fatal("an index out of range was found")
+	}
+	if _, err := lookupPath(doc, "a.x"); err == nil {
+		t.Fatal("a missing key was found")
+	}
+}
+
+func decode(t *testing.T, b []byte) interface{} {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
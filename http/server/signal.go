@@ -14,6 +14,10 @@ import (
 
 func (ep *Entrypoint) configureSignals() {
 	signal.Notify(ep.signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGUSR1)
+
+	if ep.configuration.GracefulUpgrade {
+		signal.Notify(ep.signals, syscall.SIGUSR2, syscall.SIGHUP)
+	}
 }
 
 func (ep *Entrypoint) listenSignals() {
@@ -22,6 +26,15 @@ func (ep *Entrypoint) listenSignals() {
 
 		switch sig {
 		case syscall.SIGUSR1:
+			if err := ep.ReloadCertificates(); err != nil && err != errTLSNotEnabled {
+				Logf(LevelError, "Entrypoint certificate reload: %v", err)
+			}
+		case syscall.SIGUSR2, syscall.SIGHUP:
+			if err := ep.Upgrade(); err != nil {
+				Logf(LevelError, "Entrypoint upgrade: %v", err)
+				continue
+			}
+			return
 		default:
 			ep.Stop()
 			return
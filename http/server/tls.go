@@ -0,0 +1,264 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	errNoCertificate  = errors.New("no certificate in the TLS configuration")
+	errTLSNotEnabled  = errors.New("entrypoint is not serving TLS")
+	errCertificateEnd = errors.New("certificate has expired")
+	errNoClientCA     = errors.New("client certificate verification requires client CAs")
+)
+
+// certificateStore holds the key pairs of a TLS configuration and selects them by SNI.
+type certificateStore struct {
+	pairs []CertificateConfiguration
+
+	mu       sync.RWMutex
+	certs    []*tls.Certificate
+	names    map[string]*tls.Certificate
+	modTimes []time.Time
+}
+
+func newCertificateStore(conf *TLSConfiguration) (*certificateStore, error) {
+	var pairs []CertificateConfiguration
+	if conf.Cert != "" || conf.Key != "" {
+		pairs = append(pairs, CertificateConfiguration{Cert: conf.Cert, Key: conf.Key})
+	}
+	pairs = append(pairs, conf.Certificates...)
+
+	if len(pairs) == 0 {
+		return nil, errNoCertificate
+	}
+
+	for _, pair := range pairs {
+		if exists, _ := FileExists(pair.Cert); !exists {
+			return nil, errTLSConfig
+		}
+		if exists, _ := FileExists(pair.Key); !exists {
+			return nil, errTLSConfig
+		}
+	}
+
+	s := &certificateStore{pairs: pairs}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load reads and validates all key pairs, they replace the current ones only if all are valid.
+func (s *certificateStore) load() error {
+	var (
+		certs    = make([]*tls.Certificate, 0, len(s.pairs))
+		names    = make(map[string]*tls.Certificate)
+		modTimes = make([]time.Time, 0, len(s.pairs))
+		now      = time.Now()
+	)
+
+	for _, pair := range s.pairs {
+		modTimes = append(modTimes, modTime(pair.Cert, pair.Key))
+
+		cert, err := tls.LoadX509KeyPair(pair.Cert, pair.Key)
+		if err != nil {
+			return fmt.Errorf("%s: %v", pair.Cert, err)
+		}
+
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return fmt.Errorf("%s: %v", pair.Cert, err)
+		}
+		if now.After(leaf.NotAfter) {
+			return fmt.Errorf("%s: %v", pair.Cert, errCertificateEnd)
+		}
+		cert.Leaf = leaf
+
+		certs = append(certs, &cert)
+
+		if leaf.Subject.CommonName != "" {
+			addName(names, leaf.Subject.CommonName, &cert)
+		}
+		for _, name := range leaf.DNSNames {
+			addName(names, name, &cert)
+		}
+	}
+
+	s.mu.Lock()
+	s.certs, s.names, s.modTimes = certs, names, modTimes
+	s.mu.Unlock()
+
+	return nil
+}
+
+// addName maps a host name to the certificate, the first certificate of a name wins.
+func addName(names map[string]*tls.Certificate, name string, cert *tls.Certificate) {
+	name = strings.ToLower(name)
+	if _, ok := names[name]; !ok {
+		names[name] = cert
+	}
+}
+
+func modTime(files ...string) time.Time {
+	var latest time.Time
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
+
+// changed reports whether a key pair was modified since it was loaded.
+func (s *certificateStore) changed() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for i, pair := range s.pairs {
+		if !modTime(pair.Cert, pair.Key).Equal(s.modTimes[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetCertificate selects the certificate by the exact server name, then by wildcard,
+// and falls back to the default certificate.
+func (s *certificateStore) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	name := strings.TrimSuffix(strings.ToLower(hello.ServerName), ".")
+	if name != "" {
+		if cert, ok := s.names[name]; ok {
+			return cert, nil
+		}
+
+		if i := strings.Index(name, "."); i > 0 {
+			if cert, ok := s.names["*"+name[i:]]; ok {
+				return cert, nil
+			}
+		}
+	}
+
+	return s.certs[0], nil
+}
+
+// loadCertPool reads the PEM encoded certificates of the files.
+func loadCertPool(files []string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("%s: no PEM certificate found", file)
+		}
+	}
+
+	return pool, nil
+}
+
+// Create the TLS Configuration for a http server, nil if conf is nil.
+func createTLSConfig(conf *TLSConfiguration) (*tls.Config, *certificateStore, error) {
+	if conf == nil {
+		return nil, nil, nil
+	}
+
+	store, err := newCertificateStore(conf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	minVersion := conf.MinVersion
+	if minVersion == 0 {
+		minVersion = tls.VersionTLS12
+	}
+
+	config := &tls.Config{
+		GetCertificate: store.GetCertificate,
+		MinVersion:     minVersion,
+		CipherSuites:   conf.CipherSuites,
+		NextProtos:     conf.NextProtos,
+		ClientAuth:     conf.ClientAuth,
+	}
+
+	if len(conf.ClientCAs) > 0 {
+		if config.ClientCAs, err = loadCertPool(conf.ClientCAs); err != nil {
+			return nil, nil, err
+		}
+	} else if conf.ClientAuth >= tls.VerifyClientCertIfGiven {
+		return nil, nil, errNoClientCA
+	}
+
+	return config, store, nil
+}
+
+// ReloadCertificates reloads the TLS key pairs of all listeners from their files. The
+// current certificates of a listener are kept if any of its new ones is invalid.
+func (ep *Entrypoint) ReloadCertificates() error {
+	var (
+		err     error
+		enabled bool
+	)
+
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+
+	for _, l := range ep.listeners {
+		if l.certificates == nil {
+			continue
+		}
+
+		enabled = true
+		if e := l.certificates.load(); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	if !enabled {
+		return errTLSNotEnabled
+	}
+	return err
+}
+
+// watchCertificates reloads the certificates of l when their files change, until the entrypoint stops.
+func (ep *Entrypoint) watchCertificates(l *listener) {
+	if l.certificates == nil || l.conf.TLS.ReloadInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(l.conf.TLS.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !l.certificates.changed() {
+				continue
+			}
+
+			if err := l.certificates.load(); err != nil {
+				Logf(LevelError, "Entrypoint certificate reload: %v %v", l, err)
+			}
+		case <-ep.stop:
+			return
+		}
+	}
+}
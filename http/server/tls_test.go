@@ -0,0 +1,112 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCertificate writes a self-signed key pair for names into dir.
+func writeCertificate(t *testing.T, dir, prefix string, names ...string) CertificateConfiguration {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: names[0]},
+		DNSNames:     names,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pair := CertificateConfiguration{
+		Cert: filepath.Join(dir, prefix+".crt"),
+		Key:  filepath.Join(dir, prefix+".key"),
+	}
+	if err = ioutil.WriteFile(pair.Cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(pair.Key, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return pair
+}
+
+func TestCertificateStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "apix-tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	def := writeCertificate(t, dir, "default", "default.test")
+	wildcard := writeCertificate(t, dir, "wildcard", "*.example.test")
+
+	store, err := newCertificateStore(&TLSConfiguration{
+		Cert:         def.Cert,
+		Key:          def.Key,
+		Certificates: []CertificateConfiguration{wildcard},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]string{
+		"api.example.test": "*.example.test",
+		"default.test":     "default.test",
+		"unknown.test":     "default.test",
+		"":                 "default.test",
+	}
+	for name, expected := range cases {
+		cert, _ := store.GetCertificate(&tls.ClientHelloInfo{ServerName: name})
+		if cert.Leaf.Subject.CommonName != expected {
+			t.Errorf("%q: expected %s, got %s", name, expected, cert.Leaf.Subject.CommonName)
+		}
+	}
+
+	// A broken key pair must not replace the loaded one.
+	ioutil.WriteFile(wildcard.Cert, []byte("broken"), 0600)
+	if err = store.load(); err == nil {
+		t.Fatal("expected a reload error")
+	}
+	cert, _ := store.GetCertificate(&tls.ClientHelloInfo{ServerName: "api.example.test"})
+	if cert.Leaf.Subject.CommonName != "*.example.test" {
+		t.Fatal("certificate was replaced by an invalid one")
+	}
+
+	writeCertificate(t, dir, "wildcard", "*.example.test", "www.example.test")
+	if err = store.load(); err != nil {
+		t.Fatal(err)
+	}
+	cert, _ = store.GetCertificate(&tls.ClientHelloInfo{ServerName: "www.example.test"})
+	if len(cert.Leaf.DNSNames) != 2 {
+		t.Fatal("certificate was not reloaded")
+	}
+}
@@ -0,0 +1,227 @@
+//go:build !windows
+// +build !windows
+
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package server
+
+import (
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Environment variables passing the listeners from an upgrading parent to its child.
+const (
+	envListenFDs   = "APIX_LISTEN_FDS"
+	envListenAddrs = "APIX_LISTEN_ADDRS"
+	envReadyFD     = "APIX_UPGRADE_READY_FD"
+
+	defaultUpgradeTimeout = 30 * time.Second
+)
+
+var (
+	// listenFDsStart is the first file descriptor of exec.Cmd.ExtraFiles.
+	listenFDsStart = 3
+
+	errUpgradeNotReady = errors.New("upgraded process did not report ready")
+
+	inheritedOnce sync.Once
+	inheritedMu   sync.Mutex
+	inheritedSet  map[string]net.Listener
+)
+
+type filer interface {
+	File() (*os.File, error)
+}
+
+// listenerKey identifies a listener between the parent and the child.
+func listenerKey(network, address string) string {
+	return network + "://" + address
+}
+
+// loadInherited parses the listeners passed by the parent process, once.
+func loadInherited() {
+	inheritedSet = make(map[string]net.Listener)
+
+	n, err := strconv.Atoi(os.Getenv(envListenFDs))
+	if err != nil || n <= 0 {
+		return
+	}
+
+	keys := strings.Split(os.Getenv(envListenAddrs), ",")
+	if len(keys) != n {
+		Logf(LevelWarn, "Ignoring inherited listeners: %s does not match %s", envListenAddrs, envListenFDs)
+		return
+	}
+
+	for i, key := range keys {
+		f := os.NewFile(uintptr(listenFDsStart+i), key)
+		l, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			Logf(LevelWarn, "Ignoring inherited listener %s: %v", key, err)
+			continue
+		}
+		inheritedSet[key] = l
+	}
+
+	os.Unsetenv(envListenFDs)
+	os.Unsetenv(envListenAddrs)
+}
+
+// inherited returns the listener passed by the parent for network and address, if any.
+func inherited(network, address string) (net.Listener, bool) {
+	inheritedOnce.Do(loadInherited)
+
+	inheritedMu.Lock()
+	defer inheritedMu.Unlock()
+
+	key := listenerKey(network, address)
+	l, ok := inheritedSet[key]
+	if ok {
+		delete(inheritedSet, key)
+	}
+	return l, ok
+}
+
+// notifyReady tells the upgrading parent that this process serves requests.
+func notifyReady() {
+	fd, err := strconv.Atoi(os.Getenv(envReadyFD))
+	if err != nil {
+		return
+	}
+	os.Unsetenv(envReadyFD)
+
+	f := os.NewFile(uintptr(fd), "ready")
+	f.Write([]byte{1})
+	f.Close()
+}
+
+// Upgrade starts a new process from the current executable, passing it the listening sockets.
+// Once the child reports ready the entrypoint stops gracefully, if it does not within the
+// UpgradeTimeout the child is killed and the entrypoint keeps serving.
+func (ep *Entrypoint) Upgrade() error {
+	ep.mu.Lock()
+	if ep.State() != StateRunning {
+		ep.mu.Unlock()
+		return errNotRunning
+	}
+
+	var (
+		keys  []string
+		files []*os.File
+	)
+	for _, l := range ep.listeners {
+		fl, ok := l.raw.(filer)
+		if !ok {
+			continue
+		}
+
+		f, err := fl.File()
+		if err != nil {
+			ep.mu.Unlock()
+			closeFiles(files)
+			return err
+		}
+		keys = append(keys, l.key)
+		files = append(files, f)
+	}
+	ep.mu.Unlock()
+
+	defer closeFiles(files)
+
+	path, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	cmd := exec.Command(path, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = append(files, w)
+	cmd.Env = append(upgradeEnviron(),
+		envListenFDs+"="+strconv.Itoa(len(files)),
+		envListenAddrs+"="+strings.Join(keys, ","),
+		envReadyFD+"="+strconv.Itoa(listenFDsStart+len(files)),
+	)
+
+	err = cmd.Start()
+	w.Close()
+	if err != nil {
+		return err
+	}
+
+	timeout := ep.configuration.UpgradeTimeout
+	if timeout <= 0 {
+		timeout = defaultUpgradeTimeout
+	}
+
+	ready := make(chan bool, 1)
+	go func() {
+		b := make([]byte, 1)
+		n, _ := r.Read(b)
+		ready <- n == 1
+	}()
+
+	select {
+	case ok := <-ready:
+		if ok {
+			Logf(LevelInfo, "Upgraded to process %d", cmd.Process.Pid)
+			ep.keepSocketFiles()
+			go ep.Stop()
+			go cmd.Process.Release()
+			return nil
+		}
+	case <-time.After(timeout):
+	}
+
+	cmd.Process.Kill()
+	go cmd.Wait()
+	return errUpgradeNotReady
+}
+
+func upgradeEnviron() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, envListenFDs+"=") || strings.HasPrefix(kv, envListenAddrs+"=") || strings.HasPrefix(kv, envReadyFD+"=") {
+			continue
+		}
+		env = append(env, kv)
+	}
+	return env
+}
+
+func closeFiles(files []*os.File) {
+	for _, f := range files {
+		f.Close()
+	}
+}
+
+// keepSocketFiles prevents the unix socket files, now served by the child, from being
+// removed when the listeners close.
+func (ep *Entrypoint) keepSocketFiles() {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+
+	for _, l := range ep.listeners {
+		if ul, ok := l.raw.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(false)
+		}
+	}
+}
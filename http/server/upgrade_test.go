@@ -0,0 +1,84 @@
+//go:build !windows
+// +build !windows
+
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package server
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+)
+
+// resetInherited forgets the listeners loaded from the environment.
+func resetInherited() {
+	inheritedOnce = sync.Once{}
+	inheritedSet = nil
+}
+
+func TestEntrypointInheritListener(t *testing.T) {
+	parent, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := parent.Addr().String()
+
+	f, err := parent.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd, err := syscall.Dup(int(f.Fd()))
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The socket is passed as by Upgrade, the inheriting listener takes fd over.
+	start := listenFDsStart
+	listenFDsStart = fd
+	resetInherited()
+	defer func() {
+		listenFDsStart = start
+		resetInherited()
+	}()
+
+	os.Setenv(envListenFDs, "1")
+	os.Setenv(envListenAddrs, listenerKey("tcp", addr))
+
+	// The parent still listens on the address, listening again would fail.
+	ep := NewEntrypoint(&Configuration{Address: addr}, nil)
+	err = ep.Start(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("inherited"))
+	}))
+	parent.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ep.Stop()
+
+	if os.Getenv(envListenFDs) != "" || os.Getenv(envListenAddrs) != "" {
+		t.Fatal("the inherited listeners remain in the environment")
+	}
+	if l, ok := inherited("tcp", addr); ok {
+		l.Close()
+		t.Fatal("the listener was inherited twice")
+	}
+
+	resp, err := http.Get("http://" + addr + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "inherited" {
+		t.Fatalf("unexpected body %q", body)
+	}
+}
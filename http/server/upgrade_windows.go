@@ -0,0 +1,28 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package server
+
+import (
+	"errors"
+	"net"
+)
+
+var errUpgradeUnsupported = errors.New("binary upgrade is not supported on windows")
+
+func listenerKey(network, address string) string {
+	return network + "://" + address
+}
+
+func inherited(_, _ string) (net.Listener, bool) {
+	return nil, false
+}
+
+func notifyReady() {}
+
+// Upgrade is not supported on windows.
+func (ep *Entrypoint) Upgrade() error {
+	return errUpgradeUnsupported
+}
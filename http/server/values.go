@@ -0,0 +1,96 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// contextKey is the type of keys for values stored on a request context by apix.
+type contextKey string
+
+const (
+	cspNonceKey  contextKey = "apix-csp-nonce"
+	csrfTokenKey contextKey = "apix-csrf-token"
+	forwardedKey contextKey = "apix-forwarded"
+	routeKey     contextKey = "apix-route"
+	versionKey   contextKey = "apix-api-version"
+)
+
+func withValue(r *http.Request, key contextKey, val interface{}) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), key, val))
+}
+
+func stringValue(r *http.Request, key contextKey) string {
+	if r == nil {
+		return ""
+	}
+
+	s, _ := r.Context().Value(key).(string)
+	return s
+}
+
+// WithCSPNonce returns a shallow copy of r carrying the Content-Security-Policy nonce.
+func WithCSPNonce(r *http.Request, nonce string) *http.Request {
+	return withValue(r, cspNonceKey, nonce)
+}
+
+// CSPNonce returns the Content-Security-Policy nonce of the request, for use in templates.
+func (c *Context) CSPNonce() string {
+	return stringValue(c.request, cspNonceKey)
+}
+
+// WithCSRFToken returns a shallow copy of r carrying the CSRF token to render in forms.
+func WithCSRFToken(r *http.Request, token string) *http.Request {
+	return withValue(r, csrfTokenKey, token)
+}
+
+// CSRFToken returns the CSRF token of the request, for use in templates and responses.
+func (c *Context) CSRFToken() string {
+	return stringValue(c.request, csrfTokenKey)
+}
+
+// RouteInfo receives the route matched by the Router, for middlewares which run before
+// the route is known, such as metrics labelled by route pattern.
+type RouteInfo struct {
+	// Pattern is the pattern the route was registered with, such as "/users/{id}", it is
+	// empty if no route matched.
+	Pattern string
+}
+
+// WithRouteInfo returns a shallow copy of r carrying info, which the Router fills in once
+// it has matched a route.
+func WithRouteInfo(r *http.Request, info *RouteInfo) *http.Request {
+	return withValue(r, routeKey, info)
+}
+
+// recordRoute fills in the RouteInfo of r with the route matched by the mux.
+func recordRoute(r *http.Request) {
+	info, ok := r.Context().Value(routeKey).(*RouteInfo)
+	if !ok {
+		return
+	}
+
+	info.Pattern = routePattern(r)
+}
+
+func routePattern(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return ""
+	}
+
+	pattern, _ := route.GetPathTemplate()
+	return pattern
+}
+
+// RoutePattern returns the pattern of the matched route, such as "/users/{id}".
+func (c *Context) RoutePattern() string {
+	return routePattern(c.request)
+}
@@ -0,0 +1,296 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package server
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Versioning headers.
+const (
+	HeaderAPIVersion  = "API-Version"
+	HeaderDeprecation = "Deprecation"
+	HeaderSunset      = "Sunset"
+	HeaderLink        = "Link"
+)
+
+// versionVar is the mux variable of the version in the path prefix.
+const versionVar = "apiVersion"
+
+var errBadVersion = errors.New("invalid API version")
+
+// VersionOptions selects where the Router reads the API version of a request. The
+// version is read from the path prefix, then the header, then the Accept parameter.
+type VersionOptions struct {
+	// PathPrefix serves the versioned routes under "/v{version}" too, such as "/v2/users".
+	PathPrefix bool
+	// Header is a request header carrying the version, such as "X-API-Version".
+	Header string
+	// AcceptParam is the parameter of the Accept media type carrying the version, such as
+	// "version" in "application/vnd.x+json;version=2".
+	AcceptParam string
+	// Default is the version of the requests without one, the newest if it is zero.
+	Default int
+}
+
+// Deprecation describes a deprecated API version, announced in the Deprecation, Sunset
+// and Link headers of its responses.
+type Deprecation struct {
+	// Since is the date of the deprecation, the Deprecation header is "true" if it is zero.
+	Since time.Time
+	// Sunset is the date the version stops being served, if it is known.
+	Sunset time.Time
+	// Link is the URL of the migration documentation.
+	Link string
+}
+
+// versionedRoute dispatches a pattern and its methods to the handler of a version.
+type versionedRoute struct {
+	versions  []int
+	handlers  map[int]http.HandlerFunc
+	muxRoutes []*mux.Route
+}
+
+// resolve returns the newest version not newer than requested, or the newest one if
+// requested is 0.
+func (vr *versionedRoute) resolve(requested int) (int, http.HandlerFunc) {
+	for i := len(vr.versions) - 1; i >= 0; i-- {
+		if v := vr.versions[i]; requested == 0 || v <= requested {
+			return v, vr.handlers[v]
+		}
+	}
+	return 0, nil
+}
+
+// SetVersioning enables the resolution of API versions, it must be called before the
+// versioned routes are added.
+func (rt *Router) SetVersioning(opt VersionOptions) {
+	rt.mu.Lock()
+	rt.versioning = opt
+	rt.mu.Unlock()
+}
+
+// Versioning returns the options of the resolution of API versions.
+func (rt *Router) Versioning() VersionOptions {
+	rt.mu.RLock()
+	defer rt.mu.RUnlock()
+	return rt.versioning
+}
+
+// Deprecate marks a version deprecated.
+func (rt *Router) Deprecate(version int, d Deprecation) {
+	rt.mu.Lock()
+	if rt.deprecations == nil {
+		rt.deprecations = make(map[int]Deprecation)
+	}
+	rt.deprecations[version] = d
+	rt.mu.Unlock()
+}
+
+// Version returns a VersionRouter adding the routes of an API version, starting from 1:
+//
+//	rt.SetVersioning(server.VersionOptions{PathPrefix: true, AcceptParam: "version"})
+//	rt.Version(1).Get("/users/{id}", getUserV1)
+//	rt.Version(2).Get("/users/{id}", getUserV2)
+//
+// A request is served by the newest version of the route not newer than the requested
+// one, so that a route unchanged in version 2 is still served to its clients.
+func (rt *Router) Version(version int) *VersionRouter {
+	return &VersionRouter{rt: rt, version: version}
+}
+
+// VersionRouter adds the routes of an API version to a Router.
+type VersionRouter struct {
+	rt      *Router
+	version int
+}
+
+// Get adds a route path access via GET method.
+func (vr *VersionRouter) Get(pattern string, handler HandlerFunc, filters ...FilterFunc) *Route {
+	return vr.rt.handleVersion(vr.version, pattern, vr.rt.wrapHandlerFunc(handler, filters...), GET)
+}
+
+// Post adds a route path access via POST method.
+func (vr *VersionRouter) Post(pattern string, handler HandlerFunc, filters ...FilterFunc) *Route {
+	return vr.rt.handleVersion(vr.version, pattern, vr.rt.wrapHandlerFunc(handler, filters...), POST)
+}
+
+// Put adds a route path access via PUT method.
+func (vr *VersionRouter) Put(pattern string, handler HandlerFunc, filters ...FilterFunc) *Route {
+	return vr.rt.handleVersion(vr.version, pattern, vr.rt.wrapHandlerFunc(handler, filters...), PUT)
+}
+
+// Patch adds a route path access via PATCH method.
+func (vr *VersionRouter) Patch(pattern string, handler HandlerFunc, filters ...FilterFunc) *Route {
+	return vr.rt.handleVersion(vr.version, pattern, vr.rt.wrapHandlerFunc(handler, filters...), PATCH)
+}
+
+// Delete adds a route path access via DELETE method.
+func (vr *VersionRouter) Delete(pattern string, handler HandlerFunc, filters ...FilterFunc) *Route {
+	return vr.rt.handleVersion(vr.version, pattern, vr.rt.wrapHandlerFunc(handler, filters...), DELETE)
+}
+
+// handleVersion adds the handler of a version, the mux routes of the pattern are added
+// with its first version.
+func (rt *Router) handleVersion(version int, pattern string, f http.HandlerFunc, method string) *Route {
+	if version < 1 {
+		panic("server: API versions start from 1")
+	}
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	if rt.versioned == nil {
+		rt.versioned = make(map[string]*versionedRoute)
+	}
+
+	key := method + " " + pattern
+	vr, ok := rt.versioned[key]
+	if !ok {
+		vr = &versionedRoute{handlers: make(map[int]http.HandlerFunc)}
+		rt.versioned[key] = vr
+
+		patterns := []string{pattern}
+		if rt.versioning.PathPrefix {
+			patterns = append(patterns, "/v{"+versionVar+":[0-9]+}"+pattern)
+		}
+		for _, p := range patterns {
+			route := rt.router.NewRoute().Path(p).Methods(method)
+			route.HandlerFunc(rt.limitBody(route, rt.serveVersioned(vr)))
+			vr.muxRoutes = append(vr.muxRoutes, route)
+		}
+	}
+
+	if _, exists := vr.handlers[version]; !exists {
+		vr.versions = append(vr.versions, version)
+		sort.Ints(vr.versions)
+	}
+	vr.handlers[version] = f
+
+	// The route table shows the path of the version. Without path prefix, the versions
+	// share the pattern and the OpenAPI document merges them in one operation.
+	r := &Route{Methods: []string{method}, Pattern: pattern, Version: version, router: rt, muxRoutes: vr.muxRoutes}
+	if rt.versioning.PathPrefix {
+		r.Pattern = "/v" + strconv.Itoa(version) + pattern
+	}
+	rt.routes = append(rt.routes, r)
+	return r
+}
+
+func (rt *Router) serveVersioned(vr *versionedRoute) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rt.mu.RLock()
+		opt := rt.versioning
+		requested, fromPath, err := requestedVersion(r, opt)
+		if err == nil && requested == 0 {
+			requested = opt.Default
+		}
+		version, handler := vr.resolve(requested)
+		deprecation, deprecated := rt.deprecations[version]
+		rt.mu.RUnlock()
+
+		if !fromPath {
+			for _, name := range varyHeaders(opt) {
+				w.Header().Add(HeaderVary, name)
+			}
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if handler == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		header := w.Header()
+		header.Set(HeaderAPIVersion, strconv.Itoa(version))
+		if deprecated {
+			deprecation.write(header)
+		}
+
+		handler(w, withValue(r, versionKey, version))
+	}
+}
+
+func (d *Deprecation) write(header http.Header) {
+	if d.Since.IsZero() {
+		header.Set(HeaderDeprecation, "true")
+	} else {
+		header.Set(HeaderDeprecation, "@"+strconv.FormatInt(d.Since.Unix(), 10))
+	}
+
+	if !d.Sunset.IsZero() {
+		header.Set(HeaderSunset, d.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if d.Link != "" {
+		header.Add(HeaderLink, "<"+d.Link+`>; rel="deprecation"`)
+	}
+}
+
+// requestedVersion returns the version requested by r, 0 if there is none, and whether
+// it was read from the path.
+func requestedVersion(r *http.Request, opt VersionOptions) (int, bool, error) {
+	if v, ok := mux.Vars(r)[versionVar]; ok {
+		version, err := parseVersion(v)
+		return version, true, err
+	}
+
+	if opt.Header != "" {
+		if v := r.Header.Get(opt.Header); v != "" {
+			version, err := parseVersion(v)
+			return version, false, err
+		}
+	}
+
+	if opt.AcceptParam != "" {
+		for _, mediaRange := range strings.Split(r.Header.Get(HeaderAccept), ",") {
+			for _, param := range strings.Split(mediaRange, ";")[1:] {
+				kv := strings.SplitN(param, "=", 2)
+				if len(kv) == 2 && strings.EqualFold(strings.TrimSpace(kv[0]), opt.AcceptParam) {
+					version, err := parseVersion(strings.Trim(strings.TrimSpace(kv[1]), `"`))
+					return version, false, err
+				}
+			}
+		}
+	}
+
+	return 0, false, nil
+}
+
+// varyHeaders returns the request headers selecting the version.
+func varyHeaders(opt VersionOptions) []string {
+	var headers []string
+	if opt.Header != "" {
+		headers = append(headers, opt.Header)
+	}
+	if opt.AcceptParam != "" {
+		headers = append(headers, HeaderAccept)
+	}
+	return headers
+}
+
+// parseVersion parses "2" or "v2".
+func parseVersion(s string) (int, error) {
+	version, err := strconv.Atoi(strings.TrimPrefix(strings.TrimPrefix(s, "v"), "V"))
+	if err != nil || version < 1 {
+		return 0, errBadVersion
+	}
+	return version, nil
+}
+
+// APIVersion returns the API version serving the request, 0 for an unversioned route.
+func (c *Context) APIVersion() int {
+	version, _ := c.request.Context().Value(versionKey).(int)
+	return version
+}
@@ -0,0 +1,100 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func versionHandler(name string) HandlerFunc {
+	return func(c *Context) error {
+		_, err := io.WriteString(c.Response(), name+" "+strconv.Itoa(c.APIVersion()))
+		return err
+	}
+}
+
+func versionRequest(rt *Router, target string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(GET, target, nil)
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+
+	rec := httptest.NewRecorder()
+	rt.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRouterVersion(t *testing.T) {
+	rt := NewRouter()
+	rt.SetVersioning(VersionOptions{PathPrefix: true, Header: "X-API-Version", AcceptParam: "version"})
+
+	rt.Version(1).Get("/users/{id}", versionHandler("users-v1"))
+	rt.Version(2).Get("/users/{id}", versionHandler("users-v2"))
+	rt.Version(1).Get("/orders", versionHandler("orders-v1"))
+	rt.Version(3).Get("/reports", versionHandler("reports-v3"))
+
+	for _, c := range []struct {
+		target string
+		header map[string]string
+		status int
+		body   string
+	}{
+		{"/v1/users/7", nil, http.StatusOK, "users-v1 1"},
+		{"/v2/users/7", nil, http.StatusOK, "users-v2 2"},
+		{"/v5/users/7", nil, http.StatusOK, "users-v2 2"},
+		{"/users/7", nil, http.StatusOK, "users-v2 2"},
+		{"/users/7", map[string]string{"X-API-Version": "1"}, http.StatusOK, "users-v1 1"},
+		{"/users/7", map[string]string{HeaderAccept: "application/vnd.x+json; version=1"}, http.StatusOK, "users-v1 1"},
+		{"/v2/orders", nil, http.StatusOK, "orders-v1 1"},
+		{"/v2/reports", nil, http.StatusNotFound, ""},
+		{"/users/7", map[string]string{"X-API-Version": "two"}, http.StatusBadRequest, ""},
+	} {
+		rec := versionRequest(rt, c.target, c.header)
+		if rec.Code != c.status || (c.body != "" && rec.Body.String() != c.body) {
+			t.Fatalf("%s %v: %d %q, expected %d %q", c.target, c.header, rec.Code, rec.Body.String(), c.status, c.body)
+		}
+	}
+
+	if rec := versionRequest(rt, "/users/7", nil); rec.Header().Get(HeaderAPIVersion) != "2" || len(rec.Header()[HeaderVary]) != 2 {
+		t.Fatalf("unexpected headers %v", rec.Header())
+	}
+
+	routes := rt.Routes()
+	if len(routes) != 4 || routes[1].Pattern != "/v2/users/{id}" || routes[1].Version != 2 {
+		t.Fatalf("unexpected routes %+v", routes)
+	}
+}
+
+func TestRouterDeprecation(t *testing.T) {
+	sunset := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	rt := NewRouter()
+	rt.SetVersioning(VersionOptions{Header: "X-API-Version", Default: 1})
+	rt.Deprecate(1, Deprecation{Sunset: sunset, Link: "https://example.com/migrate"})
+
+	rt.Version(1).Get("/users", versionHandler("v1"))
+	rt.Version(2).Get("/users", versionHandler("v2"))
+
+	rec := versionRequest(rt, "/users", nil)
+	if rec.Body.String() != "v1 1" {
+		t.Fatalf("the default version was not served: %q", rec.Body.String())
+	}
+	if rec.Header().Get(HeaderDeprecation) != "true" ||
+		rec.Header().Get(HeaderSunset) != "Fri, 01 Jan 2027 00:00:00 GMT" ||
+		rec.Header().Get(HeaderLink) != `<https://example.com/migrate>; rel="deprecation"` {
+		t.Fatalf("unexpected deprecation headers %v", rec.Header())
+	}
+
+	rec = versionRequest(rt, "/users", map[string]string{"X-API-Version": "v2"})
+	if rec.Body.String() != "v2 2" || rec.Header().Get(HeaderDeprecation) != "" {
+		t.Fatalf("unexpected response %q %v", rec.Body.String(), rec.Header())
+	}
+}
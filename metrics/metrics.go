@@ -0,0 +1,286 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+// Package metrics is a small registry of counters, gauges and histograms exposed in the
+// Prometheus text format, without depending on the Prometheus client.
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ContentType is the media type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefBuckets are the default histogram buckets, in seconds for latencies.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+var (
+	nameRegexp  = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// DefaultRegistry is the registry of the package level functions.
+var DefaultRegistry = NewRegistry()
+
+type kind string
+
+const (
+	kindCounter   kind = "counter"
+	kindGauge     kind = "gauge"
+	kindHistogram kind = "histogram"
+)
+
+// Registry holds the metrics and writes them in the Prometheus text format.
+type Registry struct {
+	mu      sync.RWMutex
+	metrics map[string]*metric
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]*metric)}
+}
+
+// metric is a family of series of the same name, one per label values.
+type metric struct {
+	name    string
+	help    string
+	kind    kind
+	labels  []string
+	buckets []float64
+
+	mu     sync.RWMutex
+	series map[string]*series
+}
+
+// series holds the values of the label values; a counter or a gauge uses value, a
+// histogram uses counts, sum and count.
+type series struct {
+	labelValues []string
+	value       uint64 // math.Float64bits
+	counts      []uint64
+	sum         uint64 // math.Float64bits
+	count       uint64
+}
+
+// register returns the metric called name, creating it. Registering a name again with the
+// same type and labels returns the existing metric, so clients created several times
+// share their metrics, a conflicting registration panics.
+func (r *Registry) register(name, help string, k kind, buckets []float64, labels []string) *metric {
+	if !nameRegexp.MatchString(name) {
+		panic(fmt.Sprintf("metrics: invalid metric name %q", name))
+	}
+	for _, label := range labels {
+		if !labelRegexp.MatchString(label) || strings.HasPrefix(label, "__") || (k == kindHistogram && label == "le") {
+			panic(fmt.Sprintf("metrics: invalid label name %q of %s", label, name))
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if m, ok := r.metrics[name]; ok {
+		if m.kind != k || strings.Join(m.labels, ",") != strings.Join(labels, ",") {
+			panic(fmt.Sprintf("metrics: %s is already registered as a %s with labels %v", name, m.kind, m.labels))
+		}
+		return m
+	}
+
+	m := &metric{
+		name:    name,
+		help:    help,
+		kind:    k,
+		labels:  labels,
+		buckets: buckets,
+		series:  make(map[string]*series),
+	}
+	r.metrics[name] = m
+	return m
+}
+
+// Unregister removes the metric called name, it reports whether it was registered.
+func (r *Registry) Unregister(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.metrics[name]
+	delete(r.metrics, name)
+	return ok
+}
+
+// with returns the series of the label values, creating it.
+func (m *metric) with(labelValues []string) *series {
+	if len(labelValues) != len(m.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", m.name, len(m.labels), len(labelValues)))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+
+	m.mu.RLock()
+	s, ok := m.series[key]
+	m.mu.RUnlock()
+	if ok {
+		return s
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if s, ok = m.series[key]; ok {
+		return s
+	}
+
+	s = &series{labelValues: append([]string(nil), labelValues...)}
+	if m.kind == kindHistogram {
+		s.counts = make([]uint64, len(m.buckets))
+	}
+	m.series[key] = s
+	return s
+}
+
+func addFloat(addr *uint64, delta float64) {
+	for {
+		old := atomic.LoadUint64(addr)
+		if atomic.CompareAndSwapUint64(addr, old, math.Float64bits(math.Float64frombits(old)+delta)) {
+			return
+		}
+	}
+}
+
+func loadFloat(addr *uint64) float64 {
+	return math.Float64frombits(atomic.LoadUint64(addr))
+}
+
+// Counter is a value which only goes up, such as the number of requests.
+type Counter struct {
+	m *metric
+}
+
+// NewCounter registers a counter with the label names.
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{m: r.register(name, help, kindCounter, nil, labels)}
+}
+
+// Inc adds one to the series of the label values.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds delta, which must not be negative, to the series of the label values.
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic(fmt.Sprintf("metrics: counter %s cannot decrease", c.m.name))
+	}
+	addFloat(&c.m.with(labelValues).value, delta)
+}
+
+// Value returns the value of the series of the label values.
+func (c *Counter) Value(labelValues ...string) float64 {
+	return loadFloat(&c.m.with(labelValues).value)
+}
+
+// Gauge is a value which goes up and down, such as the number of requests in flight.
+type Gauge struct {
+	m *metric
+}
+
+// NewGauge registers a gauge with the label names.
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{m: r.register(name, help, kindGauge, nil, labels)}
+}
+
+// Set sets the series of the label values to v.
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	atomic.StoreUint64(&g.m.with(labelValues).value, math.Float64bits(v))
+}
+
+// Add adds delta to the series of the label values.
+func (g *Gauge) Add(delta float64, labelValues ...string) {
+	addFloat(&g.m.with(labelValues).value, delta)
+}
+
+// Inc adds one to the series of the label values.
+func (g *Gauge) Inc(labelValues ...string) {
+	g.Add(1, labelValues...)
+}
+
+// Dec subtracts one from the series of the label values.
+func (g *Gauge) Dec(labelValues ...string) {
+	g.Add(-1, labelValues...)
+}
+
+// Value returns the value of the series of the label values.
+func (g *Gauge) Value(labelValues ...string) float64 {
+	return loadFloat(&g.m.with(labelValues).value)
+}
+
+// Histogram counts observations, such as latencies, in buckets.
+type Histogram struct {
+	m *metric
+}
+
+// NewHistogram registers a histogram with the upper bounds of the buckets, DefBuckets if
+// there are none, and the label names.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	return &Histogram{m: r.register(name, help, kindHistogram, buckets, labels)}
+}
+
+// Observe adds v to the series of the label values.
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	s := h.m.with(labelValues)
+
+	if i := sort.SearchFloat64s(h.m.buckets, v); i < len(s.counts) {
+		atomic.AddUint64(&s.counts[i], 1)
+	}
+	addFloat(&s.sum, v)
+	atomic.AddUint64(&s.count, 1)
+}
+
+// Count returns the number of observations of the series of the label values.
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	return atomic.LoadUint64(&h.m.with(labelValues).count)
+}
+
+// NewCounter registers a counter on the DefaultRegistry.
+func NewCounter(name, help string, labels ...string) *Counter {
+	return DefaultRegistry.NewCounter(name, help, labels...)
+}
+
+// NewGauge registers a gauge on the DefaultRegistry.
+func NewGauge(name, help string, labels ...string) *Gauge {
+	return DefaultRegistry.NewGauge(name, help, labels...)
+}
+
+// NewHistogram registers a histogram on the DefaultRegistry.
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return DefaultRegistry.NewHistogram(name, help, buckets, labels...)
+}
+
+// Handler serves the metrics of r in the Prometheus text format.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		r.WriteText(w)
+	})
+}
+
+// Handler serves the metrics of the DefaultRegistry.
+func Handler() http.Handler {
+	return DefaultRegistry.Handler()
+}
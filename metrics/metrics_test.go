@@ -0,0 +1,82 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	r := NewRegistry()
+
+	c := r.NewCounter("jobs_total", "Number of jobs.", "queue")
+	c.Inc("mail")
+	c.Add(2, `a"b`)
+
+	g := r.NewGauge("workers", "Number of\nworkers.")
+	g.Set(3)
+	g.Dec()
+
+	h := r.NewHistogram("job_seconds", "", []float64{1, 0.1})
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(5)
+
+	var buf bytes.Buffer
+	if err := r.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `# TYPE job_seconds histogram
+job_seconds_bucket{le="0.1"} 1
+job_seconds_bucket{le="1"} 2
+job_seconds_bucket{le="+Inf"} 3
+job_seconds_sum 5.55
+job_seconds_count 3
+# HELP jobs_total Number of jobs.
+# TYPE jobs_total counter
+jobs_total{queue="a\"b"} 2
+jobs_total{queue="mail"} 1
+# HELP workers Number of\nworkers.
+# TYPE workers gauge
+workers 2
+`
+	if buf.String() != expected {
+		t.Fatalf("unexpected exposition:\n%s", buf.String())
+	}
+}
+
+func TestRegister(t *testing.T) {
+	r := NewRegistry()
+
+	a := r.NewCounter("events_total", "", "kind")
+	b := r.NewCounter("events_total", "", "kind")
+	a.Inc("x")
+	if b.Value("x") != 1 {
+		t.Fatal("registering the same counter twice did not share it")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("a conflicting registration did not panic")
+		}
+	}()
+	r.NewGauge("events_total", "", "kind")
+}
+
+func TestLabelValues(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("events_total", "", "kind")
+
+	defer func() {
+		if e := recover(); e == nil || !strings.Contains(e.(string), "expects 1 label values") {
+			t.Fatal("missing label values did not panic:", e)
+		}
+	}()
+	c.Inc()
+}
@@ -0,0 +1,117 @@
+/*
+ * Revision History:
+ *     Initial: 2026/10/18        agent
+ */
+
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// WriteText writes the metrics sorted by name in the Prometheus text exposition format.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.RLock()
+	metrics := make([]*metric, 0, len(r.metrics))
+	for _, m := range r.metrics {
+		metrics = append(metrics, m)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(metrics, func(i, j int) bool {
+		return metrics[i].name < metrics[j].name
+	})
+
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.writeText(bw)
+	}
+	return bw.Flush()
+}
+
+func (m *metric) writeText(w *bufio.Writer) {
+	m.mu.RLock()
+	keys := make([]string, 0, len(m.series))
+	for key := range m.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	series := make([]*series, len(keys))
+	for i, key := range keys {
+		series[i] = m.series[key]
+	}
+	m.mu.RUnlock()
+
+	if m.help != "" {
+		w.WriteString("# HELP " + m.name + " " + helpEscaper.Replace(m.help) + "\n")
+	}
+	w.WriteString("# TYPE " + m.name + " " + string(m.kind) + "\n")
+
+	for _, s := range series {
+		if m.kind != kindHistogram {
+			writeSample(w, m.name, m.labels, s.labelValues, "", "", loadFloat(&s.value))
+			continue
+		}
+
+		var cumulative uint64
+		for i, bound := range m.buckets {
+			cumulative += atomic.LoadUint64(&s.counts[i])
+			writeSample(w, m.name+"_bucket", m.labels, s.labelValues, "le", formatFloat(bound), float64(cumulative))
+		}
+
+		count := atomic.LoadUint64(&s.count)
+		writeSample(w, m.name+"_bucket", m.labels, s.labelValues, "le", "+Inf", float64(count))
+		writeSample(w, m.name+"_sum", m.labels, s.labelValues, "", "", loadFloat(&s.sum))
+		writeSample(w, m.name+"_count", m.labels, s.labelValues, "", "", float64(count))
+	}
+}
+
+// writeSample writes a line such as name{method="GET",le="0.1"} 3.
+func writeSample(w *bufio.Writer, name string, labels, labelValues []string, extraLabel, extraValue string, v float64) {
+	w.WriteString(name)
+
+	if len(labels) > 0 || extraLabel != "" {
+		w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(label + `="` + labelEscaper.Replace(labelValues[i]) + `"`)
+		}
+		if extraLabel != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(extraLabel + `="` + extraValue + `"`)
+		}
+		w.WriteByte('}')
+	}
+
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
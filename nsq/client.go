@@ -6,14 +6,21 @@
 package nsq
 
 import (
+	"context"
 	"errors"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/TechCatsLab/apix/metrics"
 	"github.com/nsqio/go-nsq"
 )
 
+var (
+	publishedMessages = metrics.NewCounter("nsq_published_messages_total", "Number of messages published to nsqd.", "topic")
+	publishErrors     = metrics.NewCounter("nsq_publish_errors_total", "Number of messages which failed to publish.", "topic")
+)
+
 // Config for nsq.producer
 type Config = nsq.Config
 
@@ -75,8 +82,10 @@ func (c *Client) Publish(topic string, message string) error {
 
 	err := c.Producer.Publish(topic, []byte(message))
 	if err != nil {
+		publishErrors.Inc(topic)
 		return err
 	}
+	publishedMessages.Inc(topic)
 
 	if _, ok := c.Topics[topic]; !ok {
 		c.Topics[topic] = 'a'
@@ -135,6 +144,25 @@ func (c *Client) SetHandler(topic, channel string, handler Handler) error {
 	return nil
 }
 
+// Ping checks the producer can reach nsqd, for health checks.
+func (c *Client) Ping(ctx context.Context) error {
+	if c.Producer == nil {
+		return errors.New("no producer")
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Producer.Ping()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // NewConfig return default Config
 func NewConfig() *Config {
 	return nsq.NewConfig()